@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"kubegame/engine"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Campaign игра по наборам уровней: меню выбора и прогресс
+type Campaign struct {
+	Packs        []*engine.Pack
+	Progress     *engine.Progress
+	ProgressPath string
+
+	Pack  *engine.Pack // набор текущего уровня, nil для случайного уровня
+	Index int          // номер текущего уровня в наборе
+
+	MenuOpen      bool
+	Selected      int // выбранный в меню набор
+	SelectedLevel int // выбранный в меню уровень
+}
+
+// LoadCampaign загружает наборы уровней из каталога и прогресс игрока
+func LoadCampaign(packsDir string) *Campaign {
+	c := &Campaign{Progress: &engine.Progress{Completed: make(map[string]int)}}
+	if packs, err := engine.LoadPacks(packsDir); err == nil {
+		c.Packs = packs
+	} else if !os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "не удалось загрузить наборы уровней:", err)
+	}
+
+	if path, err := engine.DefaultProgressPath(); err == nil {
+		c.ProgressPath = path
+		if progress, err := engine.LoadProgress(path); err == nil {
+			c.Progress = progress
+		} else {
+			fmt.Fprintln(os.Stderr, "не удалось загрузить прогресс:", err)
+		}
+	}
+	return c
+}
+
+// OpenMenu открывает меню на текущем наборе и уровне
+func (c *Campaign) OpenMenu() {
+	c.MenuOpen = true
+	if c.Pack != nil {
+		c.SelectedLevel = c.Index
+		return
+	}
+	if len(c.Packs) > 0 {
+		c.SelectedLevel = c.Progress.Unlocked(c.Packs[c.Selected]) - 1
+	}
+}
+
+// HandleMenuInput обрабатывает ввод в меню; возвращает true, если выбран уровень для игры
+func (c *Campaign) HandleMenuInput() bool {
+	if rl.IsKeyPressed(rl.KeyP) || rl.IsKeyPressed(rl.KeyBackspace) {
+		c.MenuOpen = false
+		return false
+	}
+	if len(c.Packs) == 0 {
+		return false
+	}
+
+	// Выбор набора, в новом наборе сразу предлагаем первый непройденный уровень
+	if rl.IsKeyPressed(rl.KeyUp) || rl.IsKeyPressed(rl.KeyW) {
+		c.Selected = (c.Selected + len(c.Packs) - 1) % len(c.Packs)
+		c.SelectedLevel = c.Progress.Unlocked(c.Packs[c.Selected]) - 1
+	}
+	if rl.IsKeyPressed(rl.KeyDown) || rl.IsKeyPressed(rl.KeyS) {
+		c.Selected = (c.Selected + 1) % len(c.Packs)
+		c.SelectedLevel = c.Progress.Unlocked(c.Packs[c.Selected]) - 1
+	}
+
+	// Выбор уровня среди открытых
+	unlocked := c.Progress.Unlocked(c.Packs[c.Selected])
+	if rl.IsKeyPressed(rl.KeyLeft) || rl.IsKeyPressed(rl.KeyA) {
+		c.SelectedLevel = max(c.SelectedLevel-1, 0)
+	}
+	if rl.IsKeyPressed(rl.KeyRight) || rl.IsKeyPressed(rl.KeyD) {
+		c.SelectedLevel = min(c.SelectedLevel+1, unlocked-1)
+	}
+
+	return rl.IsKeyPressed(rl.KeyEnter)
+}
+
+// Play загружает уровень набора и делает его текущим
+func (c *Campaign) Play(pack *engine.Pack, index int, level *engine.Level) error {
+	l, err := pack.LoadLevel(index)
+	if err != nil {
+		return err
+	}
+	*level = l
+	c.Pack, c.Index = pack, index
+	c.MenuOpen = false
+	return nil
+}
+
+// PlaySelected загружает выбранный в меню уровень
+func (c *Campaign) PlaySelected(level *engine.Level) error {
+	return c.Play(c.Packs[c.Selected], c.SelectedLevel, level)
+}
+
+// Saved возвращает уровень набора для файла сохранения или nil,
+// если играется случайный уровень
+func (c *Campaign) Saved() *engine.SavedPack {
+	if c.Pack == nil {
+		return nil
+	}
+	return &engine.SavedPack{ID: c.Pack.ID, Index: c.Index}
+}
+
+// Resume возвращает в набор уровень из сохранения, чтобы его прохождение
+// засчиталось, и выбирает этот набор в меню; возвращает false, если такого
+// набора или уровня больше нет
+func (c *Campaign) Resume(saved *engine.SavedPack) bool {
+	for i, pack := range c.Packs {
+		if pack.ID == saved.ID && saved.Index >= 0 && saved.Index < len(pack.Levels) {
+			c.Pack, c.Index = pack, saved.Index
+			c.Selected = i
+			return true
+		}
+	}
+	return false
+}
+
+// HasNext сообщает, открыт ли следующий уровень набора
+func (c *Campaign) HasNext() bool {
+	return c.Pack != nil && c.Index+1 < c.Progress.Unlocked(c.Pack)
+}
+
+// Next загружает следующий уровень набора, если он открыт
+func (c *Campaign) Next(level *engine.Level) bool {
+	if !c.HasNext() {
+		return false
+	}
+	if err := c.Play(c.Pack, c.Index+1, level); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	return true
+}
+
+// CheckProgress отмечает пройденный уровень набора и открывает следующий
+func (c *Campaign) CheckProgress(level *engine.Level) {
+	if c.Pack == nil || !level.Won {
+		return
+	}
+	if c.Progress.Complete(c.Pack, c.Index) && c.ProgressPath != "" {
+		if err := engine.SaveProgress(c.ProgressPath, c.Progress); err != nil {
+			fmt.Fprintln(os.Stderr, "не удалось сохранить прогресс:", err)
+		}
+	}
+}
+
+// HandleCampaignInput обрабатывает клавиши наборов во время игры;
+// возвращает true, если загружен другой уровень
+func HandleCampaignInput(c *Campaign, level *engine.Level, replay *engine.Replay) bool {
+	c.CheckProgress(level)
+
+	if rl.IsKeyPressed(rl.KeyP) {
+		c.OpenMenu()
+	}
+	if rl.IsKeyPressed(rl.KeyN) && c.HasNext() {
+		saveReplay(replay)
+		if c.Next(level) {
+			replay.Reset(level)
+			return true
+		}
+	}
+	return false
+}
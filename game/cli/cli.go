@@ -0,0 +1,177 @@
+// Package cli содержит подкоманды командной строки KubeGame.
+// Команды generate, solve и validate не открывают окно, поэтому
+// подходят для CI; разбор флагов play общий для всех фронтендов.
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"kubegame/engine"
+)
+
+// Command подкоманда без экрана. Run пишет результат в stdout,
+// а ошибки отдельных уровней и предупреждения в stderr
+type Command struct {
+	Name    string
+	Summary string
+	Run     func(args []string, stdout, stderr io.Writer) error
+}
+
+// Commands подкоманды без экрана, play обрабатывает фронтенд
+var Commands = []Command{
+	{"generate", "write generated levels to disk", runGenerate},
+	{"solve", "print the optimal move string for level files", runSolve},
+	{"validate", "check that level files and packs are solvable", runValidate},
+}
+
+// Find ищет подкоманду без экрана по имени
+func Find(name string) (Command, bool) {
+	for _, c := range Commands {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Command{}, false
+}
+
+// Usage выводит список подкоманд
+func Usage(w io.Writer, program string) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n\nCommands:\n", program)
+	fmt.Fprintf(w, "  %-10s %s\n", "play", "play a level (default)")
+	for _, c := range Commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.Name, c.Summary)
+	}
+	fmt.Fprintf(w, "\nRun '%s <command> -h' for command flags.\n", program)
+}
+
+// Dispatch разбирает аргументы программы. Подкоманды без экрана выполняются
+// сразу и завершают процесс; для play (и запуска без подкоманды)
+// возвращаются разобранные параметры игры
+func Dispatch(program string, args []string) PlayOptions {
+	name := "play"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	switch name {
+	case "play":
+		opts, err := ParsePlay(args)
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return opts
+	case "help":
+		Usage(os.Stdout, program)
+		os.Exit(0)
+	}
+
+	command, ok := Find(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "неизвестная команда %q\n\n", name)
+		Usage(os.Stderr, program)
+		os.Exit(2)
+	}
+	os.Exit(command.Execute(args, os.Stdout, os.Stderr))
+	return PlayOptions{}
+}
+
+// Execute выполняет подкоманду и возвращает код завершения процесса:
+// 0 при успехе, 1 при ошибке
+func (c Command) Execute(args []string, stdout, stderr io.Writer) int {
+	// Запрошенная справка (-h) не ошибка, как и у play
+	if err := c.Run(args, stdout, stderr); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// PlayOptions параметры команды play
+type PlayOptions struct {
+	Size     engine.LevelSize
+	Level    string // файл уровня; пусто — сгенерировать новый
+	PacksDir string
+	Replay   string // файл записи для воспроизведения
+}
+
+// ParsePlay разбирает флаги команды play
+func ParsePlay(args []string) (PlayOptions, error) {
+	opts := PlayOptions{Size: engine.DefaultLevelSize()}
+	fs := flag.NewFlagSet("play", flag.ContinueOnError)
+	addSizeFlags(fs, &opts.Size)
+	fs.StringVar(&opts.Level, "level", "", "level file (.txt or .json) to play instead of a generated one")
+	fs.StringVar(&opts.PacksDir, "packs", "packs", "directory with level packs")
+	fs.StringVar(&opts.Replay, "replay", "", "replay file to play back")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	return opts, opts.Size.Validate()
+}
+
+// NewLevel загружает уровень из файла или генерирует новый
+func (o PlayOptions) NewLevel() (engine.Level, error) {
+	if o.Level != "" {
+		return LoadLevel(o.Level)
+	}
+	return engine.NewLevel(o.Size), nil
+}
+
+// addSizeFlags добавляет флаги размера, зерна, алгоритма, сложности и телепортов уровня
+func addSizeFlags(fs *flag.FlagSet, size *engine.LevelSize) {
+	fs.IntVar(&size.Width, "width", size.Width, "level width")
+	fs.IntVar(&size.Height, "height", size.Height, "level height")
+	fs.Int64Var(&size.Seed, "seed", 0, "generator seed (0 = random)")
+	fs.IntVar(&size.Target.MinMoves, "min-moves", 0, "minimal optimal solution length")
+	fs.IntVar(&size.Teleports, "teleports", 0, "number of teleporter pairs to place")
+	fs.Func("maze", "maze algorithm: "+algorithmNames(), func(name string) error {
+		a, err := engine.ParseMazeAlgorithm(name)
+		size.Algorithm = a
+		return err
+	})
+	fs.Func("difficulty", "difficulty band: any, easy, medium, hard, expert", func(name string) error {
+		b, err := engine.ParseDifficultyBand(name)
+		size.Target.Band = b
+		return err
+	})
+}
+
+// algorithmNames перечисляет названия алгоритмов лабиринта через запятую
+func algorithmNames() string {
+	var names []string
+	for a := engine.MazeAlgorithm(0); ; a++ {
+		names = append(names, strings.ToLower(a.String()))
+		if a.Next() == 0 {
+			break
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// LoadLevel загружает уровень в текстовом формате или в JSON по расширению файла
+func LoadLevel(path string) (engine.Level, error) {
+	if filepath.Ext(path) != ".json" {
+		return engine.LoadLevel(path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return engine.Level{}, err
+	}
+	var l engine.Level
+	if err := json.Unmarshal(data, &l); err != nil {
+		return engine.Level{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return l, nil
+}
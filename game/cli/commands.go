@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"kubegame/engine"
+)
+
+// runGenerate генерирует уровни и записывает их в каталог
+func runGenerate(args []string, stdout, stderr io.Writer) error {
+	size := engine.DefaultLevelSize()
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	addSizeFlags(fs, &size)
+	count := fs.Int("count", 10, "number of levels to generate")
+	out := fs.String("out", ".", "output directory")
+	format := fs.String("format", "txt", "level format: txt or json")
+	strict := fs.Bool("strict", false, "fail if a level misses the requested difficulty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := size.Validate(); err != nil {
+		return err
+	}
+	if *format != "txt" && *format != "json" {
+		return fmt.Errorf("неизвестный формат %q", *format)
+	}
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		return err
+	}
+
+	// С заданным зерном уровни получают зерна seed, seed+1 и т. д., чтобы набор
+	// был воспроизводимым. Зерно 0 означает случайный уровень, поэтому
+	// при отрицательном seed оно пропускается
+	seeded, seed := size.Seed != 0, size.Seed
+	missed := 0
+	for i := 0; i < *count; i++ {
+		if seeded {
+			size.Seed = seed
+			if seed++; seed == 0 {
+				seed++
+			}
+		}
+		l := engine.NewLevel(size)
+		path := filepath.Join(*out, fmt.Sprintf("level-%03d.%s", i+1, *format))
+		if err := writeLevel(path, &l, *format); err != nil {
+			return err
+		}
+		note := ""
+		if !l.MeetsTarget() {
+			note = "\ttarget missed"
+			missed++
+		}
+		fmt.Fprintf(stdout, "%s\t%s\t%d moves\tseed %d%s\n", path, l.Difficulty, l.OptimalMoves, l.Seed, note)
+	}
+	// Уровень вне заданной сложности все равно проходим, поэтому без -strict это
+	// только предупреждение
+	if missed > 0 {
+		err := fmt.Errorf("%d из %d уровней не попали в заданную сложность", missed, *count)
+		if *strict {
+			return err
+		}
+		fmt.Fprintln(stderr, "предупреждение:", err)
+	}
+	return nil
+}
+
+// writeLevel записывает уровень в файл в текстовом формате или в JSON
+func writeLevel(path string, l *engine.Level, format string) error {
+	if format == "txt" {
+		return engine.SaveLevel(path, l)
+	}
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// runSolve печатает оптимальное решение каждого уровня строкой из U, D, L, R
+func runSolve(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("solve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("укажите файлы уровней")
+	}
+
+	failed := 0
+	for _, path := range fs.Args() {
+		l, err := LoadLevel(path)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			failed++
+			continue
+		}
+		result := l.Solve()
+		if !result.Solvable {
+			fmt.Fprintf(stderr, "%s: нет решения\n", path)
+			failed++
+			continue
+		}
+		if fs.NArg() > 1 {
+			fmt.Fprintf(stdout, "%s\t", path)
+		}
+		fmt.Fprintln(stdout, engine.FormatMoves(result.Moves))
+	}
+	if failed > 0 {
+		return fmt.Errorf("не решено уровней: %d", failed)
+	}
+	return nil
+}
+
+// runValidate проверяет, что уровни читаются и проходимы; каталоги проверяются
+// как наборы уровней или как все файлы уровней в них
+func runValidate(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	minMoves := fs.Int("min-moves", 0, "fail levels with a shorter optimal solution")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("укажите файлы уровней или каталоги")
+	}
+
+	var paths []string
+	for _, arg := range fs.Args() {
+		found, err := levelPaths(arg)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, found...)
+	}
+
+	failed := 0
+	for _, path := range paths {
+		if err := validateLevel(path, *minMoves); err != nil {
+			fmt.Fprintf(stdout, "FAIL %s: %v\n", path, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(stdout, "ok   %s\n", path)
+	}
+	if failed > 0 {
+		return fmt.Errorf("некорректных уровней: %d из %d", failed, len(paths))
+	}
+	return nil
+}
+
+// levelPaths возвращает файлы уровней: сам файл, уровни набора или все уровни каталога
+func levelPaths(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	if _, err := os.Stat(filepath.Join(path, engine.PackMetaFile)); err == nil {
+		pack, err := engine.LoadPack(path)
+		if err != nil {
+			return nil, err
+		}
+		var paths []string
+		for _, name := range pack.Levels {
+			paths = append(paths, filepath.Join(path, name))
+		}
+		return paths, nil
+	}
+
+	var paths []string
+	err = filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != path {
+				if _, statErr := os.Stat(filepath.Join(p, engine.PackMetaFile)); statErr == nil {
+					found, err := levelPaths(p)
+					paths = append(paths, found...)
+					if err != nil {
+						return err
+					}
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		if ext := filepath.Ext(p); ext == engine.PackLevelExt || ext == ".json" && filepath.Base(p) != engine.PackMetaFile {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// validateLevel проверяет один уровень
+func validateLevel(path string, minMoves int) error {
+	l, err := LoadLevel(path)
+	if err != nil {
+		return err
+	}
+	result := l.Solve()
+	switch {
+	case !result.Solvable:
+		return fmt.Errorf("нет решения")
+	case len(result.Moves) < minMoves:
+		return fmt.Errorf("решение из %d ходов короче %d", len(result.Moves), minMoves)
+	}
+	return nil
+}
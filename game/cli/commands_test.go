@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeLevelFile записывает уровень в текстовом формате во временный каталог
+func writeLevelFile(t *testing.T, dir, name, text string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestGenerate(t *testing.T) {
+	out := t.TempDir()
+	var stdout strings.Builder
+	err := runGenerate([]string{"-count", "3", "-seed", "5", "-width", "12", "-height", "8", "-out", out}, &stdout, io.Discard)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if lines := strings.Count(stdout.String(), "\n"); lines != 3 {
+		t.Errorf("generate printed %d lines, want 3:\n%s", lines, stdout.String())
+	}
+
+	// Сгенерированные уровни проходят validate
+	stdout.Reset()
+	if err := runValidate([]string{out}, &stdout, io.Discard); err != nil {
+		t.Errorf("validate of generated levels: %v\n%s", err, stdout.String())
+	}
+}
+
+func TestGenerateSkipsZeroSeed(t *testing.T) {
+	var stdout strings.Builder
+	err := runGenerate([]string{"-count", "3", "-seed", "-1", "-width", "8", "-height", "6", "-out", t.TempDir()}, &stdout, io.Discard)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	for i, seed := range []string{"seed -1", "seed 1", "seed 2"} {
+		if i >= len(lines) || !strings.HasSuffix(lines[i], seed) {
+			t.Errorf("level %d: want %q in output:\n%s", i+1, seed, stdout.String())
+		}
+	}
+}
+
+func TestGenerateMissedTarget(t *testing.T) {
+	// На сетке 5x5 решение из 500 ходов не получить
+	args := []string{"-count", "1", "-seed", "1", "-width", "5", "-height", "5", "-min-moves", "500", "-out", t.TempDir()}
+	generate, _ := Find("generate")
+
+	var stdout, stderr strings.Builder
+	if code := generate.Execute(args, &stdout, &stderr); code != 0 {
+		t.Errorf("exit code %d, want 0\n%s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "target missed") || !strings.Contains(stderr.String(), "предупреждение") {
+		t.Errorf("missed target not reported:\nstdout %q\nstderr %q", stdout.String(), stderr.String())
+	}
+
+	stderr.Reset()
+	if code := generate.Execute(append([]string{"-strict"}, args...), io.Discard, &stderr); code != 1 {
+		t.Errorf("-strict exit code %d, want 1\n%s", code, stderr.String())
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := [][]string{
+		{"-format", "png"},
+		{"-width", "1"},
+		{"-maze", "spiral"},
+		{"-difficulty", "insane"},
+	}
+	for _, args := range tests {
+		args = append(args, "-out", t.TempDir(), "-count", "1")
+		if err := runGenerate(args, io.Discard, io.Discard); err == nil {
+			t.Errorf("generate %v succeeded", args)
+		}
+	}
+}
+
+func TestSolve(t *testing.T) {
+	dir := t.TempDir()
+	corridor := writeLevelFile(t, dir, "corridor.txt", "finish 6\nS.F\n")
+	detour := writeLevelFile(t, dir, "detour.txt", "finish 1\nS#F\n...\n")
+	walled := writeLevelFile(t, dir, "walled.txt", "finish 1\nS#F\n")
+	missing := filepath.Join(dir, "missing.txt")
+
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string
+		stderr []string // строки, которые должны быть в stderr
+	}{
+		{"one level", []string{corridor}, 0, "RR\n", nil},
+		{"several levels", []string{corridor, detour}, 0, corridor + "\tRR\n" + detour + "\tDRRU\n", nil},
+		{"no solution", []string{walled}, 1, "", []string{walled + ": нет решения", "не решено уровней: 1"}},
+		{"missing file", []string{corridor, missing}, 1, corridor + "\tRR\n", []string{missing}},
+		{"no files", nil, 1, "", []string{"укажите файлы"}},
+		{"help", []string{"-h"}, 0, "", []string{"Usage of solve"}},
+	}
+	solve, _ := Find("solve")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			if code := solve.Execute(tt.args, &stdout, &stderr); code != tt.code {
+				t.Errorf("exit code %d, want %d\n%s", code, tt.code, stderr.String())
+			}
+			if stdout.String() != tt.stdout {
+				t.Errorf("stdout %q, want %q", stdout.String(), tt.stdout)
+			}
+			for _, want := range tt.stderr {
+				if !strings.Contains(stderr.String(), want) {
+					t.Errorf("stderr %q does not contain %q", stderr.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	good := writeLevelFile(t, dir, "good.txt", "finish 1\nS#F\n...\n")
+	walled := writeLevelFile(t, dir, "walled.txt", "finish 1\nS#F\n")
+
+	tests := []struct {
+		name string
+		args []string
+		ok   bool
+	}{
+		{"solvable level", []string{good}, true},
+		{"unsolvable level", []string{walled}, false},
+		{"too short", []string{"-min-moves", "5", good}, false},
+		{"directory with a broken level", []string{dir}, false},
+		{"shipped packs", []string{filepath.Join("..", "packs")}, true},
+		{"missing path", []string{filepath.Join(dir, "missing")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout strings.Builder
+			if err := runValidate(tt.args, &stdout, io.Discard); (err == nil) != tt.ok {
+				t.Errorf("validate error %v, want ok %v\n%s", err, tt.ok, stdout.String())
+			}
+		})
+	}
+}
+
+func TestLevelPaths(t *testing.T) {
+	dir := t.TempDir()
+	level := "finish 1\nS#F\n...\n"
+	writeLevelFile(t, dir, "a.txt", level)
+	writeLevelFile(t, dir, "mypack.json", "{}")
+	writeLevelFile(t, dir, "notes.md", "")
+	pack := filepath.Join(dir, "pack")
+	if err := os.Mkdir(pack, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeLevelFile(t, pack, "pack.json", `{"levels": ["02.txt", "01.txt"]}`)
+	writeLevelFile(t, pack, "01.txt", level)
+	writeLevelFile(t, pack, "02.txt", level)
+
+	// Описание набора не уровень, а файл, имя которого только кончается на pack.json, — уровень
+	got, err := levelPaths(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(dir, "a.txt"),
+		filepath.Join(dir, "mypack.json"),
+		filepath.Join(pack, "02.txt"),
+		filepath.Join(pack, "01.txt"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("levelPaths() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,365 @@
+// Команда tui запускает KubeGame в терминале без raylib.
+// Правила игры берутся из пакета engine, раскладка экрана из пакета render.
+// Подкоманды generate, solve и validate те же, что у основной программы,
+// и не требуют графики, поэтому эту команду удобно запускать в CI.
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+
+	"kubegame/cli"
+	"kubegame/engine"
+	"kubegame/render"
+)
+
+// Ширины уровня по клавишам 1-9, как в окне raylib
+var widthKeys = map[byte]int{
+	'1': 10, '2': 12, '3': 15, '4': 18, '5': 20, '6': 25, '7': 30, '8': 35, '9': 40,
+}
+
+// Высоты уровня по клавишам, как в окне raylib (w занята движением)
+var heightKeys = map[byte]int{
+	'q': 8, 'e': 12, 't': 15, 'y': 18, 'u': 20, 'i': 25,
+}
+
+// sizeKeys подсказки клавиш размера: в терминале высота без w
+var sizeKeys = render.SizeKeys{Height: "Q,E,T-I"}
+
+// Коды клавиш, которые не являются печатными символами
+const (
+	keyCtrlC     = 0x03
+	keyCtrlD     = 0x04
+	keyBackspace = 0x7f
+	keyCtrlH     = 0x08
+	keyEscape    = 0x1b
+)
+
+// Скрытие и показ курсора
+const (
+	hideCursor = "\x1b[?25l"
+	showCursor = "\x1b[?25h"
+)
+
+// stty выполняет stty для текущего терминала
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// enableRawMode переводит терминал в посимвольный ввод без эха и возвращает функцию восстановления
+func enableRawMode() (func(), error) {
+	state, err := stty("-g")
+	if err != nil {
+		return nil, fmt.Errorf("терминал не поддерживает stty: %w", err)
+	}
+	if _, err := stty("-icanon", "-echo", "-isig", "min", "1"); err != nil {
+		return nil, err
+	}
+	fmt.Print(hideCursor)
+	return func() {
+		fmt.Print(showCursor)
+		stty(state)
+	}, nil
+}
+
+// terminalInput читает stdin в отдельной горутине, чтобы ожидание ввода
+// можно было прервать сигналом и выйти из игры обычным путем
+type terminalInput struct {
+	ctx     context.Context
+	chunks  chan []byte
+	pending []byte // прочитанный, но еще не разобранный ввод
+}
+
+// newTerminalInput начинает чтение stdin; ввод прерывается отменой ctx
+func newTerminalInput(ctx context.Context) *terminalInput {
+	in := &terminalInput{ctx: ctx, chunks: make(chan []byte)}
+	go func() {
+		for {
+			buf := make([]byte, 64)
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				close(in.chunks)
+				return
+			}
+			if n > 0 {
+				in.chunks <- buf[:n]
+			}
+		}
+	}()
+	return in
+}
+
+// read возвращает следующую порцию ввода или ошибку, если ввод
+// закончился или прерван
+func (in *terminalInput) read() ([]byte, error) {
+	if len(in.pending) > 0 {
+		chunk := in.pending
+		in.pending = nil
+		return chunk, nil
+	}
+	select {
+	case <-in.ctx.Done():
+		return nil, in.ctx.Err()
+	case chunk, ok := <-in.chunks:
+		if !ok {
+			return nil, io.EOF
+		}
+		return chunk, nil
+	}
+}
+
+// readKey читает одну клавишу; стрелки возвращаются как направление.
+// Стрелки приходят как ESC [ A-D или, в режиме приложения терминала, ESC O A-D.
+// Управляющая последовательность, начатая ESC, считается одной клавишей
+func (in *terminalInput) readKey() (byte, engine.Direction, bool, error) {
+	buf, err := in.read()
+	if err != nil {
+		return 0, 0, false, err
+	}
+	if buf[0] != keyEscape {
+		in.pending = buf[1:]
+		return buf[0], 0, false, nil
+	}
+	if len(buf) >= 3 && (buf[1] == '[' || buf[1] == 'O') {
+		arrows := map[byte]engine.Direction{'A': engine.Up, 'B': engine.Down, 'C': engine.Right, 'D': engine.Left}
+		if dir, ok := arrows[buf[2]]; ok {
+			// Зажатая стрелка может прийти несколькими последовательностями подряд
+			in.pending = buf[3:]
+			return 0, dir, true, nil
+		}
+	}
+	return keyEscape, 0, false, nil
+}
+
+// handleKey обрабатывает клавишу; возвращает false, если нужно выйти,
+// и replaced, если уровень заменен новым или вставленным
+func handleKey(in *terminalInput, level *engine.Level, currentSize *engine.LevelSize, hint *engine.Hint, key byte) (ok, replaced bool) {
+	if width, ok := widthKeys[key]; ok {
+		currentSize.Width = width
+	}
+	if height, ok := heightKeys[key]; ok {
+		currentSize.Height = height
+	}
+
+	switch key {
+	case keyCtrlC, keyCtrlD:
+		return false, false
+	case 'm':
+		currentSize.Algorithm = currentSize.Algorithm.Next()
+	case 'l':
+		currentSize.Target.Band = currentSize.NextBand()
+	case 'r':
+		*level = engine.NewLevel(*currentSize)
+		replaced = true
+	case 'R':
+		level.Restart()
+	case 'z', keyBackspace, keyCtrlH:
+		level.Undo()
+	case 'x':
+		level.Redo()
+	case 'h':
+		hint.Toggle()
+	case 'c':
+		copyShareCode(level)
+	case 'v':
+		if pasted, err := engine.DecodeShareCode(in.readLine("Level code: ")); err == nil {
+			*level = pasted
+			replaced = true
+		}
+	}
+
+	if level.Won {
+		return true, replaced
+	}
+
+	// Движение по WASD
+	moves := map[byte]engine.Direction{'w': engine.Up, 's': engine.Down, 'a': engine.Left, 'd': engine.Right}
+	if dir, ok := moves[key]; ok {
+		level.TryMove(dir)
+	}
+	return true, replaced
+}
+
+// copyShareCode копирует код уровня в буфер обмена терминала (OSC 52)
+func copyShareCode(level *engine.Level) {
+	code, err := engine.EncodeShareCode(level)
+	if err != nil {
+		return
+	}
+	fmt.Printf("\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(code)))
+}
+
+// readLine читает строку с эхом до Enter; вставка из буфера обмена приходит как обычный ввод
+func (in *terminalInput) readLine(prompt string) string {
+	fmt.Print("\r\n" + prompt + showCursor)
+	defer fmt.Print(hideCursor)
+
+	var line []byte
+	for {
+		buf, err := in.read()
+		if err != nil {
+			return string(line)
+		}
+		for i, ch := range buf {
+			switch {
+			case ch == '\r' || ch == '\n':
+				in.pending = buf[i+1:]
+				return string(line)
+			case ch == keyBackspace || ch == keyCtrlH:
+				if len(line) > 0 {
+					line = line[:len(line)-1]
+					fmt.Print("\b \b")
+				}
+			case ch == keyCtrlC || ch == keyEscape:
+				return ""
+			case ch >= ' ':
+				line = append(line, ch)
+				fmt.Printf("%c", ch)
+			}
+		}
+	}
+}
+
+// draw выводит текущий кадр в терминал
+func draw(level *engine.Level, currentSize engine.LevelSize, hint *engine.Hint, resumePrompt bool) error {
+	hint.Update(level)
+	renderer := render.NewTerminalRenderer(level.Size.Width, level.Size.Height)
+	render.DrawLevel(renderer, level, hint)
+	render.DrawLevelSizeUI(renderer, currentSize, sizeKeys)
+	render.DrawUI(renderer, level, hint, sizeKeys)
+	if resumePrompt {
+		render.DrawResumePrompt(renderer)
+	}
+	if err := renderer.Flush(os.Stdout); err != nil {
+		return err
+	}
+	_, err := fmt.Print("\r\nCtrl+C: quit\r\n")
+	return err
+}
+
+func main() {
+	opts := cli.Dispatch("tui", os.Args[1:])
+	if opts.Replay != "" {
+		fmt.Fprintln(os.Stderr, "воспроизведение записей доступно только в окне raylib")
+		os.Exit(2)
+	}
+
+	// Настройки генератора и уровень из файла, если он задан.
+	// Размер загруженного уровня в настройки не попадает
+	currentSize := opts.Size
+	level, err := opts.NewLevel()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	restore, err := enableRawMode()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer restore()
+
+	// Внешнее прерывание прекращает ожидание ввода, и игра завершается
+	// обычным путем: срабатывают отложенные сохранение и restore
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	input := newTerminalInput(ctx)
+
+	// Незаконченную игру показываем сразу и предлагаем продолжить,
+	// если уровень не задан явно в командной строке. Уровень набора,
+	// начатый в окне, остается уровнем набора: меню наборов в терминале нет,
+	// но прохождение засчитывается в прогресс
+	savePath, _ := engine.DefaultSavePath()
+	resumePrompt := false
+	var pack *engine.SavedPack
+	if savePath != "" && opts.Level == "" {
+		if saved, savedPack, err := engine.LoadGame(savePath); err == nil {
+			level, pack = saved, savedPack
+			resumePrompt = true
+		}
+	}
+
+	// Сохраняем незаконченную игру при выходе
+	defer func() {
+		if savePath == "" {
+			return
+		}
+		if level.Won {
+			engine.RemoveSave(savePath)
+		} else if err := engine.SaveGame(savePath, &level, pack); err != nil {
+			fmt.Fprintln(os.Stderr, "не удалось сохранить игру:", err)
+		}
+	}()
+
+	// Подсказка считается только при показе, см. engine.Hint
+	var hint engine.Hint
+	for {
+		if err := draw(&level, currentSize, &hint, resumePrompt); err != nil {
+			return
+		}
+		key, dir, arrow, err := input.readKey()
+		if err != nil {
+			return
+		}
+		if resumePrompt {
+			switch key {
+			case '\r', '\n':
+				resumePrompt = false
+			case 'n', 'N':
+				level = engine.NewLevel(currentSize)
+				pack = nil
+				resumePrompt = false
+			case keyCtrlC, keyCtrlD:
+				return
+			}
+			continue
+		}
+		if arrow {
+			if !level.Won {
+				level.TryMove(dir)
+			}
+		} else {
+			ok, replaced := handleKey(input, &level, &currentSize, &hint, key)
+			if !ok {
+				return
+			}
+			if replaced {
+				pack = nil
+			}
+		}
+
+		// Пройденный уровень набора отмечаем в прогрессе сразу, пока его не заменили
+		if level.Won && pack != nil {
+			completePackLevel(pack)
+			pack = nil
+		}
+	}
+}
+
+// completePackLevel отмечает уровень набора пройденным в файле прогресса
+func completePackLevel(saved *engine.SavedPack) {
+	path, err := engine.DefaultProgressPath()
+	if err != nil {
+		return
+	}
+	progress, err := engine.LoadProgress(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "не удалось загрузить прогресс:", err)
+		return
+	}
+	if progress.Complete(&engine.Pack{ID: saved.ID}, saved.Index) {
+		if err := engine.SaveProgress(path, progress); err != nil {
+			fmt.Fprintln(os.Stderr, "не удалось сохранить прогресс:", err)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package engine
+
+import "fmt"
+
+// Die представляет кубик с отслеживанием всех сторон
+type Die struct {
+	Top, Bottom, Front, Back, Left, Right int
+	CurrentTop                            int
+}
+
+// Direction направление движения
+type Direction int
+
+const (
+	Up Direction = iota
+	Down
+	Left
+	Right
+)
+
+// Directions все направления движения
+var Directions = []Direction{Up, Down, Left, Right}
+
+// Delta возвращает смещение по сетке для направления
+func (d Direction) Delta() (dx, dy int) {
+	switch d {
+	case Up:
+		return 0, -1
+	case Down:
+		return 0, 1
+	case Left:
+		return -1, 0
+	case Right:
+		return 1, 0
+	}
+	return 0, 0
+}
+
+// String возвращает название направления
+func (d Direction) String() string {
+	switch d {
+	case Up:
+		return "Up"
+	case Down:
+		return "Down"
+	case Left:
+		return "Left"
+	case Right:
+		return "Right"
+	}
+	return "None"
+}
+
+// ParseDirection разбирает название направления, как его возвращает String
+func ParseDirection(s string) (Direction, error) {
+	for _, dir := range Directions {
+		if dir.String() == s {
+			return dir, nil
+		}
+	}
+	return 0, fmt.Errorf("неизвестное направление %q", s)
+}
+
+// MarshalText записывает направление его названием
+func (d Direction) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText читает направление по названию
+func (d *Direction) UnmarshalText(text []byte) error {
+	dir, err := ParseDirection(string(text))
+	if err != nil {
+		return err
+	}
+	*d = dir
+	return nil
+}
+
+// NewDie создает новый кубик
+func NewDie() Die {
+	return Die{
+		Top:        1,
+		Bottom:     6,
+		Front:      2,
+		Back:       5,
+		Left:       3,
+		Right:      4,
+		CurrentTop: 1,
+	}
+}
+
+// Roll перекатывает кубик в указанном направлении
+func (d *Die) Roll(dir Direction) {
+	switch dir {
+	case Up:
+		newTop := d.Back
+		d.Back = d.Bottom
+		d.Bottom = d.Front
+		d.Front = d.Top
+		d.Top = newTop
+	case Down:
+		newTop := d.Front
+		d.Front = d.Bottom
+		d.Bottom = d.Back
+		d.Back = d.Top
+		d.Top = newTop
+	case Left:
+		newTop := d.Right
+		d.Right = d.Bottom
+		d.Bottom = d.Left
+		d.Left = d.Top
+		d.Top = newTop
+	case Right:
+		newTop := d.Left
+		d.Left = d.Bottom
+		d.Bottom = d.Right
+		d.Right = d.Top
+		d.Top = newTop
+	}
+	d.CurrentTop = d.Top
+}
+
+// Rotate поворачивает кубик на месте на 90° вокруг вертикальной оси:
+// по часовой стрелке, если смотреть на экран сверху, или против нее.
+// На экране Back обращена вверх, а Front вниз, поэтому по часовой стрелке
+// Back уходит вправо, Right вниз и т. д. Верх и низ не меняются
+func (d *Die) Rotate(clockwise bool) {
+	if clockwise {
+		d.Front, d.Right, d.Back, d.Left = d.Right, d.Back, d.Left, d.Front
+	} else {
+		d.Front, d.Right, d.Back, d.Left = d.Left, d.Front, d.Right, d.Back
+	}
+}
+
+// DieOrientations возвращает все 24 ориентации кубика, достижимые перекатыванием,
+// в постоянном порядке; первой идет ориентация NewDie
+func DieOrientations() []Die {
+	start := NewDie()
+	seen := map[Die]bool{start: true}
+	orientations := []Die{start}
+	for i := 0; i < len(orientations); i++ {
+		for _, dir := range Directions {
+			next := orientations[i]
+			next.Roll(dir)
+			if !seen[next] {
+				seen[next] = true
+				orientations = append(orientations, next)
+			}
+		}
+	}
+	return orientations
+}
+
+// Orientation возвращает номер ориентации кубика в DieOrientations
+// или -1, если такой кубик нельзя получить перекатыванием
+func (d Die) Orientation() int {
+	for i, o := range DieOrientations() {
+		if o == d {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,105 @@
+package engine
+
+import "testing"
+
+func TestDieRoll(t *testing.T) {
+	tests := []struct {
+		dir  Direction
+		want Die
+	}{
+		{Up, Die{Top: 5, Bottom: 2, Front: 1, Back: 6, Left: 3, Right: 4, CurrentTop: 5}},
+		{Down, Die{Top: 2, Bottom: 5, Front: 6, Back: 1, Left: 3, Right: 4, CurrentTop: 2}},
+		{Left, Die{Top: 4, Bottom: 3, Front: 2, Back: 5, Left: 1, Right: 6, CurrentTop: 4}},
+		{Right, Die{Top: 3, Bottom: 4, Front: 2, Back: 5, Left: 6, Right: 1, CurrentTop: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir.String(), func(t *testing.T) {
+			die := NewDie()
+			die.Roll(tt.dir)
+			if die != tt.want {
+				t.Errorf("Roll(%s) = %+v, want %+v", tt.dir, die, tt.want)
+			}
+		})
+	}
+}
+
+func TestDieRollReturns(t *testing.T) {
+	opposite := map[Direction]Direction{Up: Down, Down: Up, Left: Right, Right: Left}
+	for _, start := range DieOrientations() {
+		for _, dir := range Directions {
+			die := start
+			die.Roll(dir)
+			die.Roll(opposite[dir])
+			if die != start {
+				t.Errorf("%+v: Roll(%s) and back gives %+v", start, dir, die)
+			}
+
+			// Четыре переката в одну сторону возвращают кубик в исходное положение
+			die = start
+			for i := 0; i < 4; i++ {
+				die.Roll(dir)
+			}
+			if die != start {
+				t.Errorf("%+v: four Roll(%s) give %+v", start, dir, die)
+			}
+		}
+	}
+}
+
+func TestDieOrientations(t *testing.T) {
+	orientations := DieOrientations()
+	if len(orientations) != 24 {
+		t.Fatalf("len(DieOrientations()) = %d, want 24", len(orientations))
+	}
+	if orientations[0] != NewDie() {
+		t.Errorf("DieOrientations()[0] = %+v, want NewDie", orientations[0])
+	}
+	for i, die := range orientations {
+		if got := die.Orientation(); got != i {
+			t.Errorf("%+v.Orientation() = %d, want %d", die, got, i)
+		}
+	}
+}
+
+func TestDieRotate(t *testing.T) {
+	tests := []struct {
+		name      string
+		clockwise bool
+		want      Die
+	}{
+		// Back сверху экрана уходит вправо, Right вниз, Front влево, Left вверх
+		{"clockwise", true, Die{Top: 1, Bottom: 6, Front: 4, Back: 3, Left: 2, Right: 5, CurrentTop: 1}},
+		{"counter-clockwise", false, Die{Top: 1, Bottom: 6, Front: 3, Back: 4, Left: 5, Right: 2, CurrentTop: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			die := NewDie()
+			die.Rotate(tt.clockwise)
+			if die != tt.want {
+				t.Errorf("Rotate(%v) = %+v, want %+v", tt.clockwise, die, tt.want)
+			}
+		})
+	}
+}
+
+func TestDieRotateReturns(t *testing.T) {
+	for _, start := range DieOrientations() {
+		die := start
+		die.Rotate(true)
+		if die.Orientation() < 0 {
+			t.Errorf("%+v: Rotate(true) gives impossible die %+v", start, die)
+		}
+		die.Rotate(false)
+		if die != start {
+			t.Errorf("%+v: Rotate(true) and Rotate(false) give %+v", start, die)
+		}
+
+		die = start
+		for i := 0; i < 4; i++ {
+			die.Rotate(true)
+		}
+		if die != start {
+			t.Errorf("%+v: four Rotate(true) give %+v", start, die)
+		}
+	}
+}
@@ -0,0 +1,524 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+// Пороги итоговой оценки для словесного рейтинга сложности
+// Оценка не зависит от размера поля, но запас для мутаций зависит:
+// какие диапазоны подгонка достигает на уровне данного размера,
+// говорит LevelSize.Reaches, а промах виден через Level.MeetsTarget
+const (
+	MediumDifficultyScore = 30
+	HardDifficultyScore   = 36
+	ExpertDifficultyScore = 44
+)
+
+const (
+	// DifficultyBudget сколько клеток суммарно могут занимать варианты
+	// лабиринта, перебранные при подгонке сложности. Попыток тем меньше,
+	// чем больше уровень, поэтому время подгонки от размера почти не зависит
+	DifficultyBudget = 100000
+
+	// MutationsPerMaze сколько мутаций подряд без улучшения пробовать
+	// перед новой генерацией
+	MutationsPerMaze = 30
+)
+
+// point клетка уровня
+type point struct{ x, y int }
+
+// Difficulty оценка сложности уровня по его оптимальному решению
+type Difficulty struct {
+	OptimalMoves int     // длина кратчайшего решения
+	Loops        int     // возвраты решения в уже пройденную клетку с другой ориентацией
+	Branching    float64 // среднее число доступных ходов вдоль решения
+	DeadEnds     int     // тупики среди достижимых клеток
+	Detour       float64 // во сколько раз решение длиннее манхэттенского расстояния до финиша
+	Score        float64 // итоговая оценка
+}
+
+// Rating возвращает словесную оценку сложности
+func (d Difficulty) Rating() string {
+	switch {
+	case d.Score >= ExpertDifficultyScore:
+		return "Expert"
+	case d.Score >= HardDifficultyScore:
+		return "Hard"
+	case d.Score >= MediumDifficultyScore:
+		return "Medium"
+	default:
+		return "Easy"
+	}
+}
+
+// String возвращает рейтинг вместе с оценкой
+func (d Difficulty) String() string {
+	return fmt.Sprintf("%s (%.0f)", d.Rating(), d.Score)
+}
+
+// RateDifficulty вычисляет сложность уровня по решению с учетом ориентации кубика
+func (l *Level) RateDifficulty() Difficulty {
+	return l.rateSolution(l.Solve())
+}
+
+// rateSolution вычисляет сложность уровня по уже найденному решению
+func (l *Level) rateSolution(result SolveResult) Difficulty {
+	if !result.Solvable {
+		return Difficulty{}
+	}
+
+	d := Difficulty{OptimalMoves: len(result.Moves)}
+
+	// Проходим по решению, считая петли и развилки
+	current := l.StartPlayer()
+	seen := map[point]bool{{current.X, current.Y}: true}
+	options := 0
+	for _, dir := range result.Moves {
+		for _, option := range Directions {
+			if _, ok := l.NextState(current, option); ok {
+				options++
+			}
+		}
+
+		current, _ = l.NextState(current, dir)
+		if seen[point{current.X, current.Y}] {
+			d.Loops++
+		}
+		seen[point{current.X, current.Y}] = true
+	}
+	if d.OptimalMoves > 0 {
+		d.Branching = float64(options) / float64(d.OptimalMoves)
+	}
+
+	d.Detour = 1
+	if distance := abs(l.Finish.X-l.Start.X) + abs(l.Finish.Y-l.Start.Y); distance > 0 {
+		d.Detour = float64(d.OptimalMoves) / float64(distance)
+	}
+
+	reachable := 0
+	d.DeadEnds, reachable = l.countDeadEnds()
+
+	d.Score = 10*d.Detour +
+		5*float64(d.Loops) +
+		5*d.Branching +
+		50*float64(d.DeadEnds)/float64(reachable)
+	return d
+}
+
+// countDeadEnds считает достижимые от старта клетки с единственным выходом
+// и общее число достижимых клеток
+func (l *Level) countDeadEnds() (deadEnds, reachable int) {
+	start := point{l.Start.X, l.Start.Y}
+	visited := map[point]bool{start: true}
+	queue := []point{start}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		exits := 0
+		for _, dir := range Directions {
+			dx, dy := dir.Delta()
+			nx, ny := current.x+dx, current.y+dy
+			if !l.isOpen(nx, ny) {
+				continue
+			}
+			// Телепорт сразу переносит на парную клетку
+			if cell := l.Cells[ny][nx]; cell.IsTeleport() {
+				nx, ny = cell.PairX, cell.PairY
+			}
+			exits++
+			if !visited[point{nx, ny}] {
+				visited[point{nx, ny}] = true
+				queue = append(queue, point{nx, ny})
+			}
+		}
+
+		isStart := current == start
+		isFinish := current.x == l.Finish.X && current.y == l.Finish.Y
+		if exits == 1 && !isStart && !isFinish {
+			deadEnds++
+		}
+	}
+	return deadEnds, len(visited)
+}
+
+// DifficultyBand желаемый диапазон сложности
+type DifficultyBand int
+
+const (
+	BandAny DifficultyBand = iota
+	BandEasy
+	BandMedium
+	BandHard
+	BandExpert
+	difficultyBandCount
+)
+
+// String возвращает название диапазона
+func (b DifficultyBand) String() string {
+	switch b {
+	case BandEasy:
+		return "Easy"
+	case BandMedium:
+		return "Medium"
+	case BandHard:
+		return "Hard"
+	case BandExpert:
+		return "Expert"
+	default:
+		return "Any"
+	}
+}
+
+// Next возвращает следующий диапазон по кругу
+func (b DifficultyBand) Next() DifficultyBand {
+	return (b + 1) % difficultyBandCount
+}
+
+// ParseDifficultyBand ищет диапазон сложности по названию без учета регистра
+func ParseDifficultyBand(name string) (DifficultyBand, error) {
+	for b := BandAny; b < difficultyBandCount; b++ {
+		if strings.EqualFold(b.String(), name) {
+			return b, nil
+		}
+	}
+	return 0, fmt.Errorf("неизвестная сложность %q", name)
+}
+
+// Bounds возвращает границы оценки для диапазона
+func (b DifficultyBand) Bounds() (low, high float64) {
+	switch b {
+	case BandEasy:
+		return 0, MediumDifficultyScore
+	case BandMedium:
+		return MediumDifficultyScore, HardDifficultyScore
+	case BandHard:
+		return HardDifficultyScore, ExpertDifficultyScore
+	case BandExpert:
+		return ExpertDifficultyScore, math.Inf(1)
+	default:
+		return 0, math.Inf(1)
+	}
+}
+
+// areaRange допустимые площади уровня
+type areaRange struct{ min, max int }
+
+// reachableAreas площади уровня, на которых подгонка за DifficultyBudget
+// попадает в диапазон сложности на всех 12 проверенных зернах.
+// Открытые Scatter и Caves трудно усложнить, а идеальные лабиринты
+// упростить, и на больших уровнях им не хватает попыток; на самых
+// маленьких оценка меняется скачками через средние диапазоны
+var reachableAreas = [...][difficultyBandCount]areaRange{
+	AlgorithmScatter:     {BandEasy: {0, 2000}, BandMedium: {0, 600}, BandHard: {0, 240}, BandExpert: {0, 120}},
+	AlgorithmBacktracker: {BandEasy: {0, 1350}, BandMedium: {48, 1350}, BandHard: {0, 2000}, BandExpert: {0, 1000}},
+	AlgorithmPrim:        {BandEasy: {0, 600}, BandMedium: {48, 2000}, BandHard: {0, 2000}, BandExpert: {0, 240}},
+	AlgorithmKruskal:     {BandEasy: {0, 875}, BandMedium: {48, 2000}, BandHard: {0, 2000}, BandExpert: {0, 240}},
+	AlgorithmWilson:      {BandEasy: {0, 600}, BandMedium: {48, 2000}, BandHard: {0, 2000}, BandExpert: {0, 600}},
+	AlgorithmCaves:       {BandEasy: {0, 2000}, BandMedium: {48, 375}, BandHard: {48, 240}, BandExpert: {80, 150}},
+}
+
+// Reaches проверяет, попадает ли подгонка сложности в диапазон b на уровне
+// такого размера и алгоритма. Недостижимый диапазон генератор тоже пробует,
+// но обычно оставляет ближайший к нему уровень
+func (s LevelSize) Reaches(b DifficultyBand) bool {
+	if b == BandAny {
+		return true
+	}
+	algorithm := s.Algorithm
+	if algorithm < 0 || int(algorithm) >= len(reachableAreas) {
+		algorithm = AlgorithmScatter
+	}
+	areas := reachableAreas[algorithm][b]
+	area := s.Width * s.Height
+	return area >= areas.min && area <= areas.max
+}
+
+// NextBand возвращает следующий после s.Target.Band диапазон, достижимый
+// на уровне такого размера и алгоритма
+func (s LevelSize) NextBand() DifficultyBand {
+	b := s.Target.Band.Next()
+	for !s.Reaches(b) {
+		b = b.Next()
+	}
+	return b
+}
+
+// DifficultyTarget требования к сложности генерируемого уровня
+type DifficultyTarget struct {
+	Band     DifficultyBand
+	MinMoves int // минимальная длина оптимального решения, 0 — без ограничения
+}
+
+// Distance возвращает, насколько сложность далека от требуемой (0 — подходит)
+func (t DifficultyTarget) Distance(d Difficulty) float64 {
+	distance := 0.0
+	low, high := t.Band.Bounds()
+	if d.Score < low {
+		distance += low - d.Score
+	}
+	if d.Score >= high {
+		distance += d.Score - high + 1
+	}
+	if d.OptimalMoves < t.MinMoves {
+		distance += float64(t.MinMoves - d.OptimalMoves)
+	}
+	return distance
+}
+
+// Matches проверяет, подходит ли сложность под требования
+func (t DifficultyTarget) Matches(d Difficulty) bool {
+	return t.Distance(d) == 0
+}
+
+// MeetsTarget проверяет, попала ли сложность уровня в l.Size.Target.
+// Генератор оставляет ближайший уровень, если подогнать сложность не удалось
+func (l *Level) MeetsTarget() bool {
+	return l.Size.Target.Matches(l.Difficulty)
+}
+
+// fitDifficulty мутирует и перегенерирует лабиринт, пока его сложность
+// не попадет в l.Size.Target. Если это не удалось, остается ближайший вариант
+func (l *Level) fitDifficulty(rng *rand.Rand) {
+	target := l.Size.Target
+	currentDistance := l.pickFinishNumber(target)
+	best, bestNumber, bestDistance := l.cloneCells(), l.Finish.Number, currentDistance
+	path := l.solutionCells(l.Solve().Moves)
+	stalled := 0
+
+	attempts := DifficultyBudget / (l.Size.Width * l.Size.Height)
+	for attempt := 1; attempt <= attempts && bestDistance > 0; attempt++ {
+		if stalled >= MutationsPerMaze {
+			// Мутации зашли в тупик: начинаем с нового лабиринта
+			l.generateSolvable(rng)
+			currentDistance = l.pickFinishNumber(target)
+			path = l.solutionCells(l.Solve().Moves)
+			stalled = 0
+		} else {
+			// Слишком просто — перекрываем решение, слишком сложно — открываем
+			// короткий путь рядом с ним. Неудачная мутация откатывается
+			low, _ := target.Band.Bounds()
+			harder := l.Difficulty.Score < low || l.OptimalMoves < target.MinMoves
+			saved, savedNumber, savedDifficulty := l.cloneCells(), l.Finish.Number, l.Difficulty
+			distance, mutatedPath := math.Inf(1), path
+			if l.mutate(rng, harder, path) {
+				distance, mutatedPath = l.rateMutation(target)
+			}
+			if l.OptimalMoves == 0 || distance > currentDistance {
+				l.Cells, l.Finish.Number = saved, savedNumber
+				l.Difficulty, l.OptimalMoves = savedDifficulty, savedDifficulty.OptimalMoves
+				stalled++
+				continue
+			}
+
+			// Равноценные мутации тоже принимаем: они меняют лабиринт
+			// и открывают путь к следующим улучшениям
+			if distance < currentDistance {
+				stalled = 0
+			} else {
+				stalled++
+			}
+			currentDistance, path = distance, mutatedPath
+		}
+
+		if currentDistance < bestDistance {
+			best, bestNumber, bestDistance = l.cloneCells(), l.Finish.Number, currentDistance
+		}
+	}
+
+	l.Cells = best
+	l.Finish.Number = bestNumber
+	l.Difficulty = l.RateDifficulty()
+	l.OptimalMoves = l.Difficulty.OptimalMoves
+}
+
+// rateMutation оценивает уровень после мутации и возвращает расстояние до
+// требуемой сложности и клетки нового решения. Другое число на финише
+// выбирается, только если с прежним уровень стал непроходимым: перебор
+// чисел в разы дороже
+func (l *Level) rateMutation(target DifficultyTarget) (float64, []point) {
+	result := l.Solve()
+	l.Difficulty = l.rateSolution(result)
+	l.OptimalMoves = l.Difficulty.OptimalMoves
+	if l.OptimalMoves == 0 {
+		distance := l.pickFinishNumber(target)
+		return distance, l.solutionCells(l.Solve().Moves)
+	}
+	return target.Distance(l.Difficulty), l.solutionCells(result.Moves)
+}
+
+// mutate меняет лабиринт рядом с оптимальным решением path и возвращает false,
+// если менять нечего. Чтобы усложнить уровень, решение перекрывается
+// отрезком стены; если он разрезал лабиринт, части соединяются проходом
+// в другом месте, и кубику приходится идти в обход. Чтобы упростить,
+// убирается стена рядом с решением
+func (l *Level) mutate(rng *rand.Rand, harder bool, path []point) bool {
+	var candidates []point
+	for _, p := range path {
+		if harder && l.isMutable(p.x, p.y) {
+			candidates = append(candidates, p)
+		}
+		for _, dir := range Directions {
+			dx, dy := dir.Delta()
+			next := point{p.x + dx, p.y + dy}
+			if !harder && l.isMutable(next.x, next.y) && l.Cells[next.y][next.x].IsWall {
+				candidates = append(candidates, next)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return false
+	}
+	p := candidates[rng.Intn(len(candidates))]
+	if !harder {
+		l.Cells[p.y][p.x].IsWall = false
+		return true
+	}
+
+	// Отрезок стены через клетку решения, длина растет с размером уровня
+	dx, dy := 1, 0
+	if rng.Intn(2) == 0 {
+		dx, dy = 0, 1
+	}
+	reach := rng.Intn(max(l.Size.Width, l.Size.Height)/4 + 1)
+	cut := map[point]bool{}
+	for i := -reach; i <= reach; i++ {
+		x, y := p.x+i*dx, p.y+i*dy
+		if l.isMutable(x, y) && !l.Cells[y][x].IsWall {
+			l.Cells[y][x].IsWall = true
+			cut[point{x, y}] = true
+		}
+	}
+	return l.reconnect(rng, cut)
+}
+
+// reconnect соединяет часть лабиринта со стартом и часть с финишем, если
+// стены cut их разделили: открывает стену на их границе, кроме самих cut.
+// Чаще всего выбирается проход, дающий самый длинный обход, иначе
+// случайный. Возвращает false, если соединить не удалось
+func (l *Level) reconnect(rng *rand.Rand, cut map[point]bool) bool {
+	fromStart := l.openDistances(point{l.Start.X, l.Start.Y})
+	if _, ok := fromStart[point{l.Finish.X, l.Finish.Y}]; ok {
+		return true
+	}
+	fromFinish := l.openDistances(point{l.Finish.X, l.Finish.Y})
+
+	var candidates []point
+	longest, longestDetour := point{}, -1
+	for y := 0; y < l.Size.Height; y++ {
+		for x := 0; x < l.Size.Width; x++ {
+			if !l.Cells[y][x].IsWall || cut[point{x, y}] || !l.isMutable(x, y) {
+				continue
+			}
+			toStart, toFinish := -1, -1
+			for _, dir := range Directions {
+				dx, dy := dir.Delta()
+				if d, ok := fromStart[point{x + dx, y + dy}]; ok && (toStart < 0 || d < toStart) {
+					toStart = d
+				}
+				if d, ok := fromFinish[point{x + dx, y + dy}]; ok && (toFinish < 0 || d < toFinish) {
+					toFinish = d
+				}
+			}
+			if toStart < 0 || toFinish < 0 {
+				continue
+			}
+			candidates = append(candidates, point{x, y})
+			if toStart+toFinish > longestDetour {
+				longest, longestDetour = point{x, y}, toStart+toFinish
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return false
+	}
+	p := longest
+	if rng.Intn(2) == 0 {
+		p = candidates[rng.Intn(len(candidates))]
+	}
+	l.Cells[p.y][p.x].IsWall = false
+	return true
+}
+
+// isMutable проверяет, что клетку можно превратить в стену и обратно:
+// это не рамка, не старт, не финиш и не особая клетка
+func (l *Level) isMutable(x, y int) bool {
+	if x <= 0 || y <= 0 || x >= l.Size.Width-1 || y >= l.Size.Height-1 {
+		return false
+	}
+	isStart := x == l.Start.X && y == l.Start.Y
+	isFinish := x == l.Finish.X && y == l.Finish.Y
+	return !isStart && !isFinish && !l.Cells[y][x].IsSpecial()
+}
+
+// solutionCells возвращает клетки, через которые проходят ходы решения
+func (l *Level) solutionCells(moves []Direction) []point {
+	current := l.StartPlayer()
+	var cells []point
+	for _, dir := range moves {
+		current, _ = l.NextState(current, dir)
+		cells = append(cells, point{current.X, current.Y})
+	}
+	return cells
+}
+
+// openDistances возвращает расстояния от from до клеток, достижимых
+// по открытым клеткам без учета кубика
+func (l *Level) openDistances(from point) map[point]int {
+	distances := map[point]int{from: 0}
+	queue := []point{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dir := range Directions {
+			dx, dy := dir.Delta()
+			next := point{current.x + dx, current.y + dy}
+			if _, seen := distances[next]; l.isOpen(next.x, next.y) && !seen {
+				distances[next] = distances[current] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+	return distances
+}
+
+// pickFinishNumber выбирает из достижимых чисел на финише то, при котором
+// сложность ближе всего к требуемой, и возвращает это расстояние
+func (l *Level) pickFinishNumber(target DifficultyTarget) float64 {
+	bestNumber := l.Finish.Number
+	bestDistance := math.Inf(1)
+	for _, number := range l.ReachableTops(l.Finish.X, l.Finish.Y) {
+		l.Finish.Number = number
+		if distance := target.Distance(l.RateDifficulty()); distance < bestDistance {
+			bestNumber = number
+			bestDistance = distance
+		}
+	}
+
+	l.Finish.Number = bestNumber
+	l.Difficulty = l.RateDifficulty()
+	l.OptimalMoves = l.Difficulty.OptimalMoves
+	return bestDistance
+}
+
+// cloneCells возвращает копию сетки клеток
+func (l *Level) cloneCells() [][]Cell {
+	cells := make([][]Cell, len(l.Cells))
+	for y := range l.Cells {
+		cells[y] = append([]Cell(nil), l.Cells[y]...)
+	}
+	return cells
+}
+
+// abs возвращает модуль числа
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,149 @@
+package engine
+
+import "testing"
+
+func TestRateDifficulty(t *testing.T) {
+	tests := []struct {
+		name  string
+		level string
+		want  Difficulty
+	}{
+		{"unsolvable", "finish 1\nS#F\n", Difficulty{}},
+		{"corridor", "finish 6\nS.F\n", Difficulty{OptimalMoves: 2, Branching: 1.5, Detour: 1, Score: 17.5}},
+		{"dead end", "finish 6\nS.F\n.##\n", Difficulty{OptimalMoves: 2, Branching: 2, DeadEnds: 1, Detour: 1, Score: 32.5}},
+		{"detour", "finish 1\nS#F\n...\n", Difficulty{OptimalMoves: 4, Branching: 1.75, Detour: 2, Score: 28.75}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := parseTestLevel(t, tt.level)
+			if got := l.RateDifficulty(); got != tt.want {
+				t.Errorf("RateDifficulty() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDifficultyRating(t *testing.T) {
+	tests := []struct {
+		score float64
+		want  string
+	}{
+		{0, "Easy"},
+		{MediumDifficultyScore - 1, "Easy"},
+		{MediumDifficultyScore, "Medium"},
+		{HardDifficultyScore, "Hard"},
+		{ExpertDifficultyScore + 100, "Expert"},
+	}
+	for _, tt := range tests {
+		if got := (Difficulty{Score: tt.score}).Rating(); got != tt.want {
+			t.Errorf("Rating(%v) = %q, want %q", tt.score, got, tt.want)
+		}
+	}
+}
+
+func TestDifficultyTargetDistance(t *testing.T) {
+	tests := []struct {
+		name   string
+		target DifficultyTarget
+		d      Difficulty
+		want   float64
+	}{
+		{"any", DifficultyTarget{}, Difficulty{Score: 100, OptimalMoves: 1}, 0},
+		{"inside band", DifficultyTarget{Band: BandMedium}, Difficulty{Score: MediumDifficultyScore}, 0},
+		{"below band", DifficultyTarget{Band: BandHard}, Difficulty{Score: HardDifficultyScore - 5}, 5},
+		{"at upper bound", DifficultyTarget{Band: BandMedium}, Difficulty{Score: HardDifficultyScore}, 1},
+		{"too short", DifficultyTarget{MinMoves: 10}, Difficulty{OptimalMoves: 7}, 3},
+		{"both", DifficultyTarget{Band: BandExpert, MinMoves: 10}, Difficulty{Score: ExpertDifficultyScore - 2, OptimalMoves: 9}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.target.Distance(tt.d); got != tt.want {
+				t.Errorf("Distance = %v, want %v", got, tt.want)
+			}
+			if got := tt.target.Matches(tt.d); got != (tt.want == 0) {
+				t.Errorf("Matches = %v, want %v", got, tt.want == 0)
+			}
+		})
+	}
+}
+
+func TestLevelSizeReaches(t *testing.T) {
+	tests := []struct {
+		name string
+		size LevelSize
+		band DifficultyBand
+		want bool
+	}{
+		{"any band", LevelSize{Width: 50, Height: 40, Algorithm: AlgorithmCaves}, BandAny, true},
+		{"default size", LevelSize{Width: 15, Height: 10, Algorithm: AlgorithmBacktracker}, BandExpert, true},
+		{"large caves", LevelSize{Width: 50, Height: 40, Algorithm: AlgorithmCaves}, BandExpert, false},
+		{"small perfect maze", LevelSize{Width: 5, Height: 5, Algorithm: AlgorithmPrim}, BandMedium, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.size.Reaches(tt.band); got != tt.want {
+				t.Errorf("Reaches(%s) = %v, want %v", tt.band, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLevelSizeNextBand(t *testing.T) {
+	tests := []struct {
+		name string
+		size LevelSize
+		want DifficultyBand
+	}{
+		{"next", LevelSize{Width: 15, Height: 10, Algorithm: AlgorithmBacktracker, Target: DifficultyTarget{Band: BandHard}}, BandExpert},
+		{"wraps", LevelSize{Width: 15, Height: 10, Algorithm: AlgorithmBacktracker, Target: DifficultyTarget{Band: BandExpert}}, BandAny},
+		{"skips unreachable", LevelSize{Width: 50, Height: 40, Algorithm: AlgorithmCaves, Target: DifficultyTarget{Band: BandEasy}}, BandAny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.size.NextBand(); got != tt.want {
+				t.Errorf("NextBand() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewLevelFitsDifficulty(t *testing.T) {
+	// Каждый диапазон, который LevelSize.Reaches считает достижимым,
+	// подгонка находит за DifficultyBudget
+	sizes := []LevelSize{
+		{Width: 10, Height: 8}, {Width: 15, Height: 10}, {Width: 25, Height: 15},
+		{Width: 40, Height: 25}, {Width: 50, Height: 40},
+	}
+	forEachAlgorithm(t, func(t *testing.T, a MazeAlgorithm) {
+		for _, size := range sizes {
+			size.Algorithm = a
+			for band := BandEasy; band <= BandExpert; band++ {
+				if !size.Reaches(band) {
+					continue
+				}
+				for seed := int64(1); seed <= 3; seed++ {
+					size.Seed, size.Target = seed, DifficultyTarget{Band: band}
+					l := NewLevel(size)
+					if !l.MeetsTarget() {
+						t.Errorf("%dx%d seed %d: difficulty %s outside %s", size.Width, size.Height, seed, l.Difficulty, band)
+					}
+					if l.Difficulty != l.RateDifficulty() {
+						t.Errorf("%dx%d seed %d: stored difficulty %+v, rated %+v", size.Width, size.Height, seed, l.Difficulty, l.RateDifficulty())
+					}
+				}
+			}
+		}
+	})
+}
+
+func TestNewLevelReportsMissedTarget(t *testing.T) {
+	// На самой маленькой сетке решение из 500 ходов не получить, но генератор
+	// все равно возвращает проходимый уровень
+	l := NewLevel(LevelSize{Width: 5, Height: 5, Seed: 1, Target: DifficultyTarget{MinMoves: 500}})
+	if l.MeetsTarget() {
+		t.Errorf("%d moves reported as meeting the target", l.OptimalMoves)
+	}
+	if !l.Solve().Solvable {
+		t.Error("level is not solvable")
+	}
+}
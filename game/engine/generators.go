@@ -0,0 +1,409 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// MazeGenerator расставляет стены лабиринта на уже созданной сетке клеток
+type MazeGenerator interface {
+	Name() string
+	Generate(l *Level, rng *rand.Rand)
+}
+
+// MazeAlgorithm алгоритм генерации лабиринта
+type MazeAlgorithm int
+
+const (
+	AlgorithmScatter     MazeAlgorithm = iota // случайные стены на каждой третьей линии
+	AlgorithmBacktracker                      // идеальный лабиринт (Recursive Backtracking)
+	AlgorithmPrim                             // идеальный лабиринт (алгоритм Прима)
+	AlgorithmKruskal                          // идеальный лабиринт (алгоритм Краскала)
+	AlgorithmWilson                           // идеальный лабиринт (алгоритм Уилсона)
+	AlgorithmCaves                            // пещеры на клеточном автомате
+)
+
+// mazeGenerators генераторы, индекс совпадает с MazeAlgorithm
+var mazeGenerators = []MazeGenerator{
+	AlgorithmScatter:     ScatterGenerator{},
+	AlgorithmBacktracker: BacktrackerGenerator{},
+	AlgorithmPrim:        PrimGenerator{},
+	AlgorithmKruskal:     KruskalGenerator{},
+	AlgorithmWilson:      WilsonGenerator{},
+	AlgorithmCaves:       CaveGenerator{},
+}
+
+// Generator возвращает генератор для алгоритма
+func (a MazeAlgorithm) Generator() MazeGenerator {
+	if a < 0 || int(a) >= len(mazeGenerators) {
+		return ScatterGenerator{}
+	}
+	return mazeGenerators[a]
+}
+
+// String возвращает название алгоритма
+func (a MazeAlgorithm) String() string {
+	return a.Generator().Name()
+}
+
+// Next возвращает следующий алгоритм по кругу
+func (a MazeAlgorithm) Next() MazeAlgorithm {
+	return (a + 1) % MazeAlgorithm(len(mazeGenerators))
+}
+
+// ParseMazeAlgorithm ищет алгоритм по названию без учета регистра
+func ParseMazeAlgorithm(name string) (MazeAlgorithm, error) {
+	for a := range mazeGenerators {
+		if strings.EqualFold(MazeAlgorithm(a).String(), name) {
+			return MazeAlgorithm(a), nil
+		}
+	}
+	return 0, fmt.Errorf("неизвестный алгоритм лабиринта %q", name)
+}
+
+// ScatterGenerator расставляет случайные стены с гарантированным путем от старта к финишу
+type ScatterGenerator struct{}
+
+func (ScatterGenerator) Name() string { return "Scatter" }
+
+func (ScatterGenerator) Generate(l *Level, rng *rand.Rand) {
+	// Добавляем внешние стены
+	for x := 0; x < l.Size.Width; x++ {
+		l.Cells[0][x].IsWall = true
+		l.Cells[l.Size.Height-1][x].IsWall = true
+	}
+	for y := 0; y < l.Size.Height; y++ {
+		l.Cells[y][0].IsWall = true
+		l.Cells[y][l.Size.Width-1].IsWall = true
+	}
+
+	// Добавляем внутренние стены (лабиринт)
+	// Создаем несколько вертикальных и горизонтальных стен
+	for y := 2; y < l.Size.Height-2; y += 3 {
+		for x := 1; x < l.Size.Width-1; x++ {
+			if rng.Float64() < WallDensity { // вероятность стены
+				l.Cells[y][x].IsWall = true
+			}
+		}
+	}
+
+	for x := 2; x < l.Size.Width-2; x += 3 {
+		for y := 1; y < l.Size.Height-1; y++ {
+			if rng.Float64() < WallDensity { // вероятность стены
+				l.Cells[y][x].IsWall = true
+			}
+		}
+	}
+
+	// Создаем гарантированный путь от старта к финишу
+	l.createGuaranteedPath(rng)
+
+	// Добавляем случайные открытые проходы для соединения областей
+	l.connectIsolatedAreas(rng)
+
+	// Создаем хотя бы одну 2x2 открытую область
+	l.createOpenSpace2x2(rng)
+}
+
+// BacktrackerGenerator строит идеальный лабиринт алгоритмом Recursive Backtracking
+type BacktrackerGenerator struct{}
+
+func (BacktrackerGenerator) Name() string { return "Backtracker" }
+
+func (BacktrackerGenerator) Generate(l *Level, rng *rand.Rand) {
+	l.fillWalls()
+	l.carvePassagesFrom(1, 1, rng)
+	l.connectRoomsToStartAndFinish()
+}
+
+// carvePassagesFrom рекурсивно прокладывает проходы из комнаты (x, y)
+func (l *Level) carvePassagesFrom(x, y int, rng *rand.Rand) {
+	l.Cells[y][x].Visited = true
+	l.Cells[y][x].IsWall = false
+
+	directions := []Direction{Up, Down, Left, Right}
+	rng.Shuffle(len(directions), func(i, j int) {
+		directions[i], directions[j] = directions[j], directions[i]
+	})
+
+	for _, dir := range directions {
+		dx, dy := dir.Delta()
+		nx, ny := x+2*dx, y+2*dy
+		if !l.isRoom(nx, ny) || l.Cells[ny][nx].Visited {
+			continue
+		}
+		// Пробиваем стену между комнатами
+		l.Cells[y+dy][x+dx].IsWall = false
+		l.carvePassagesFrom(nx, ny, rng)
+	}
+}
+
+// PrimGenerator строит идеальный лабиринт рандомизированным алгоритмом Прима
+type PrimGenerator struct{}
+
+func (PrimGenerator) Name() string { return "Prim" }
+
+func (PrimGenerator) Generate(l *Level, rng *rand.Rand) {
+	l.fillWalls()
+
+	type room struct{ x, y int }
+
+	// Граница: комнаты вне лабиринта, соседние с ним
+	var frontier []room
+	addFrontier := func(x, y int) {
+		for _, dir := range Directions {
+			dx, dy := dir.Delta()
+			nx, ny := x+2*dx, y+2*dy
+			if l.isRoom(nx, ny) && l.Cells[ny][nx].IsWall && !l.Cells[ny][nx].Visited {
+				l.Cells[ny][nx].Visited = true
+				frontier = append(frontier, room{nx, ny})
+			}
+		}
+	}
+
+	l.Cells[1][1].IsWall = false
+	l.Cells[1][1].Visited = true
+	addFrontier(1, 1)
+
+	for len(frontier) > 0 {
+		i := rng.Intn(len(frontier))
+		current := frontier[i]
+		frontier[i] = frontier[len(frontier)-1]
+		frontier = frontier[:len(frontier)-1]
+
+		// Соединяем комнату со случайным соседом, уже входящим в лабиринт
+		var neighbors []Direction
+		for _, dir := range Directions {
+			dx, dy := dir.Delta()
+			nx, ny := current.x+2*dx, current.y+2*dy
+			if l.isRoom(nx, ny) && !l.Cells[ny][nx].IsWall {
+				neighbors = append(neighbors, dir)
+			}
+		}
+		dx, dy := neighbors[rng.Intn(len(neighbors))].Delta()
+		l.Cells[current.y][current.x].IsWall = false
+		l.Cells[current.y+dy][current.x+dx].IsWall = false
+
+		addFrontier(current.x, current.y)
+	}
+
+	l.connectRoomsToStartAndFinish()
+}
+
+// KruskalGenerator строит идеальный лабиринт рандомизированным алгоритмом Краскала
+type KruskalGenerator struct{}
+
+func (KruskalGenerator) Name() string { return "Kruskal" }
+
+func (KruskalGenerator) Generate(l *Level, rng *rand.Rand) {
+	l.fillWalls()
+
+	// Система непересекающихся множеств по индексам комнат
+	parent := make([]int, l.Size.Width*l.Size.Height)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	// Собираем все стены между соседними комнатами
+	type wall struct{ x, y, dx, dy int }
+	var walls []wall
+	for y := 1; y < l.Size.Height-1; y += 2 {
+		for x := 1; x < l.Size.Width-1; x += 2 {
+			l.Cells[y][x].IsWall = false
+			if l.isRoom(x+2, y) {
+				walls = append(walls, wall{x, y, 1, 0})
+			}
+			if l.isRoom(x, y+2) {
+				walls = append(walls, wall{x, y, 0, 1})
+			}
+		}
+	}
+	rng.Shuffle(len(walls), func(i, j int) {
+		walls[i], walls[j] = walls[j], walls[i]
+	})
+
+	for _, w := range walls {
+		a := find(w.y*l.Size.Width + w.x)
+		b := find((w.y+2*w.dy)*l.Size.Width + w.x + 2*w.dx)
+		if a != b {
+			parent[a] = b
+			l.Cells[w.y+w.dy][w.x+w.dx].IsWall = false
+		}
+	}
+
+	l.connectRoomsToStartAndFinish()
+}
+
+// WilsonGenerator строит идеальный лабиринт алгоритмом Уилсона
+// (случайные блуждания со стиранием петель дают равномерно случайный лабиринт)
+type WilsonGenerator struct{}
+
+func (WilsonGenerator) Name() string { return "Wilson" }
+
+func (WilsonGenerator) Generate(l *Level, rng *rand.Rand) {
+	l.fillWalls()
+
+	type room struct{ x, y int }
+	var rooms []room
+	for y := 1; y < l.Size.Height-1; y += 2 {
+		for x := 1; x < l.Size.Width-1; x += 2 {
+			rooms = append(rooms, room{x, y})
+		}
+	}
+
+	// Visited отмечает комнаты, уже вошедшие в лабиринт
+	l.Cells[1][1].Visited = true
+	l.Cells[1][1].IsWall = false
+
+	// Последнее направление выхода из каждой комнаты при блуждании
+	exits := make(map[room]Direction)
+
+	for _, start := range rooms {
+		if l.Cells[start.y][start.x].Visited {
+			continue
+		}
+
+		// Блуждаем, пока не попадем в лабиринт; перезапись выхода стирает петли
+		current := start
+		for !l.Cells[current.y][current.x].Visited {
+			var options []Direction
+			for _, dir := range Directions {
+				dx, dy := dir.Delta()
+				if l.isRoom(current.x+2*dx, current.y+2*dy) {
+					options = append(options, dir)
+				}
+			}
+			dir := options[rng.Intn(len(options))]
+			exits[current] = dir
+			dx, dy := dir.Delta()
+			current = room{current.x + 2*dx, current.y + 2*dy}
+		}
+
+		// Прокладываем путь без петель
+		current = start
+		for !l.Cells[current.y][current.x].Visited {
+			dx, dy := exits[current].Delta()
+			l.Cells[current.y][current.x].Visited = true
+			l.Cells[current.y][current.x].IsWall = false
+			l.Cells[current.y+dy][current.x+dx].IsWall = false
+			current = room{current.x + 2*dx, current.y + 2*dy}
+		}
+	}
+
+	l.connectRoomsToStartAndFinish()
+}
+
+// CaveFillChance начальная доля стен для клеточного автомата
+const CaveFillChance = 0.45
+
+// CaveSmoothSteps количество шагов сглаживания пещер
+const CaveSmoothSteps = 4
+
+// CaveGenerator строит пещеры клеточным автоматом
+type CaveGenerator struct{}
+
+func (CaveGenerator) Name() string { return "Caves" }
+
+func (CaveGenerator) Generate(l *Level, rng *rand.Rand) {
+	width, height := l.Size.Width, l.Size.Height
+
+	// Случайное заполнение, края всегда стены
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			border := x == 0 || y == 0 || x == width-1 || y == height-1
+			l.Cells[y][x].IsWall = border || rng.Float64() < CaveFillChance
+		}
+	}
+
+	// Сглаживание: клетка становится стеной, если вокруг нее много стен
+	for step := 0; step < CaveSmoothSteps; step++ {
+		next := make([][]bool, height)
+		for y := 0; y < height; y++ {
+			next[y] = make([]bool, width)
+			for x := 0; x < width; x++ {
+				if x == 0 || y == 0 || x == width-1 || y == height-1 {
+					next[y][x] = true
+					continue
+				}
+				walls := 0
+				for dy := -1; dy <= 1; dy++ {
+					for dx := -1; dx <= 1; dx++ {
+						if l.Cells[y+dy][x+dx].IsWall {
+							walls++
+						}
+					}
+				}
+				next[y][x] = walls >= 5
+			}
+		}
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				l.Cells[y][x].IsWall = next[y][x]
+			}
+		}
+	}
+
+	// Соединяем старт с финишем и засыпаем недостижимые пещеры
+	l.createGuaranteedPath(rng)
+	l.fillUnreachable()
+}
+
+// fillWalls заполняет всю сетку стенами
+func (l *Level) fillWalls() {
+	for y := 0; y < l.Size.Height; y++ {
+		for x := 0; x < l.Size.Width; x++ {
+			l.Cells[y][x].IsWall = true
+			l.Cells[y][x].Visited = false
+		}
+	}
+}
+
+// isRoom проверяет, является ли клетка комнатой сетки идеального лабиринта
+// Комнаты лежат в клетках с нечетными координатами внутри внешних стен
+func (l *Level) isRoom(x, y int) bool {
+	return x >= 1 && x < l.Size.Width-1 && y >= 1 && y < l.Size.Height-1 &&
+		x%2 == 1 && y%2 == 1
+}
+
+// connectRoomsToStartAndFinish соединяет старт и финиш с ближайшими комнатами
+func (l *Level) connectRoomsToStartAndFinish() {
+	// Соединяем старт с первой комнатой
+	l.Cells[0][0].IsWall = false
+	l.Cells[0][1].IsWall = false
+
+	// Соединяем финиш с ближайшей к нему комнатой
+	roomX, roomY := l.Size.Width-2, l.Size.Height-2
+	if roomX%2 == 0 {
+		roomX--
+	}
+	if roomY%2 == 0 {
+		roomY--
+	}
+	for x := roomX; x <= l.Finish.X; x++ {
+		l.Cells[roomY][x].IsWall = false
+	}
+	for y := roomY; y <= l.Finish.Y; y++ {
+		l.Cells[y][l.Finish.X].IsWall = false
+	}
+}
+
+// fillUnreachable превращает в стены все клетки, недостижимые от старта
+func (l *Level) fillUnreachable() {
+	l.Cells[l.Start.Y][l.Start.X].IsWall = false
+	l.EnsureConnectivity()
+	for y := 0; y < l.Size.Height; y++ {
+		for x := 0; x < l.Size.Width; x++ {
+			if !l.Cells[y][x].Visited {
+				l.Cells[y][x].IsWall = true
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// newGeneratedLevel создает сетку уровня и заполняет ее генератором алгоритма a
+func newGeneratedLevel(a MazeAlgorithm, width, height int, seed int64) Level {
+	l := Level{Size: LevelSize{Width: width, Height: height, Algorithm: a}}
+	l.Finish.X, l.Finish.Y = width-1, height-1
+	l.GenerateMaze(rand.New(rand.NewSource(seed)))
+	return l
+}
+
+func TestPerfectMazeGenerators(t *testing.T) {
+	for _, a := range []MazeAlgorithm{AlgorithmBacktracker, AlgorithmPrim, AlgorithmKruskal, AlgorithmWilson} {
+		t.Run(a.String(), func(t *testing.T) {
+			for seed := int64(1); seed <= 5; seed++ {
+				l := newGeneratedLevel(a, 15, 11, seed)
+
+				// Идеальный лабиринт — дерево: все узлы с нечетными координатами
+				// открыты, и между n узлами прорублено ровно n-1 проходов
+				nodes, open := 0, 0
+				for y := 1; y < l.Size.Height-1; y++ {
+					for x := 1; x < l.Size.Width-1; x++ {
+						if x%2 == 1 && y%2 == 1 {
+							nodes++
+							if l.Cells[y][x].IsWall {
+								t.Errorf("seed %d: node (%d,%d) is a wall", seed, x, y)
+							}
+						}
+						if !l.Cells[y][x].IsWall {
+							open++
+						}
+					}
+				}
+				if open != 2*nodes-1 {
+					t.Errorf("seed %d: %d open cells for %d nodes, want %d", seed, open, nodes, 2*nodes-1)
+				}
+			}
+		})
+	}
+}
+
+func TestCaveGeneratorConnected(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		l := newGeneratedLevel(AlgorithmCaves, 20, 12, seed)
+
+		// Все открытые клетки пещер достижимы от старта
+		reachable := map[[2]int]bool{{0, 0}: true}
+		queue := [][2]int{{0, 0}}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			for _, dir := range Directions {
+				dx, dy := dir.Delta()
+				next := [2]int{current[0] + dx, current[1] + dy}
+				if l.isOpen(next[0], next[1]) && !reachable[next] {
+					reachable[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+		for y := range l.Cells {
+			for x, cell := range l.Cells[y] {
+				if !cell.IsWall && !reachable[[2]int{x, y}] {
+					t.Errorf("seed %d: open cell (%d,%d) is unreachable", seed, x, y)
+				}
+			}
+		}
+	}
+}
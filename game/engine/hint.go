@@ -0,0 +1,53 @@
+package engine
+
+import "sync/atomic"
+
+// HintMode режим подсказки
+type HintMode int
+
+const (
+	HintOff  HintMode = iota // подсказка скрыта
+	HintNext                 // показывается следующий оптимальный ход
+	HintPath                 // показывается весь оставшийся путь
+	hintModeCount
+)
+
+// Next возвращает следующий режим подсказки по кругу
+func (m HintMode) Next() HintMode {
+	return (m + 1) % hintModeCount
+}
+
+// lastLevelID последний выданный номер уровня, см. Level.id
+var lastLevelID atomic.Uint64
+
+// Hint подсказка, которую держит фронтенд: режим и решение из состояния игрока.
+// Уровень подсказку не хранит, поэтому ходы не запускают решатель; он
+// запускается в Update, только пока подсказка показана
+type Hint struct {
+	Mode   HintMode
+	Result SolveResult
+
+	// Состояние, из которого посчитано Result: номер уровня и игрок
+	level uint64
+	from  Player
+}
+
+// Toggle переключает режим подсказки
+func (h *Hint) Toggle() {
+	h.Mode = h.Mode.Next()
+}
+
+// Update пересчитывает подсказку для текущего состояния уровня. Решение
+// ищется заново, только если игрок сдвинулся или уровень сменился
+func (h *Hint) Update(l *Level) {
+	if h.Mode == HintOff {
+		h.Result, h.level = SolveResult{}, 0
+		return
+	}
+	// Уровень без номера (собранный вручную) пересчитывается всегда
+	if l.id != 0 && h.level == l.id && h.from == l.Player {
+		return
+	}
+	h.Result = l.SolveFrom(l.Player)
+	h.level, h.from = l.id, l.Player
+}
@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHintToggle(t *testing.T) {
+	l := parseTestLevel(t, "finish 6\nS.F\n")
+	var hint Hint
+	tests := []struct {
+		mode  HintMode
+		moves []Direction
+	}{
+		{HintNext, []Direction{Right, Right}},
+		{HintPath, []Direction{Right, Right}},
+		{HintOff, nil},
+	}
+	for _, tt := range tests {
+		hint.Toggle()
+		hint.Update(&l)
+		if hint.Mode != tt.mode || !reflect.DeepEqual(hint.Result.Moves, tt.moves) {
+			t.Errorf("Toggle() gives mode %d, moves %v; want %d, %v", hint.Mode, hint.Result.Moves, tt.mode, tt.moves)
+		}
+	}
+}
+
+func TestHintUpdate(t *testing.T) {
+	l := parseTestLevel(t, "finish 1\nS#F\n...\n")
+	hint := Hint{Mode: HintNext}
+	hint.Update(&l)
+	l.TryMove(Down)
+	hint.Update(&l)
+	if want := []Direction{Right, Right, Up}; !hint.Result.Solvable || !reflect.DeepEqual(hint.Result.Moves, want) {
+		t.Errorf("hint after Down = %+v, want moves %v", hint.Result, want)
+	}
+
+	// Другой уровень с игроком в той же позиции получает свою подсказку
+	l = parseTestLevel(t, "finish 6\nS.F\n...\n")
+	l.TryMove(Down)
+	hint.Update(&l)
+	if want := []Direction{Up, Right, Right}; !reflect.DeepEqual(hint.Result.Moves, want) {
+		t.Errorf("hint on a new level = %v, want %v", hint.Result.Moves, want)
+	}
+
+	// Из состояния без решения подсказка пустая
+	l = parseTestLevel(t, "finish 6\nS.F\n.##\n")
+	l.Player = Player{X: 2, Y: 0, Die: NewDie()}
+	hint.Update(&l)
+	if hint.Result.Solvable {
+		t.Errorf("hint from an unsolvable state = %+v, want unsolvable", hint.Result)
+	}
+}
+
+func TestHintUpdateCaches(t *testing.T) {
+	l := NewLevel(LevelSize{Width: 6, Height: 5, Seed: 3})
+	hint := Hint{Mode: HintNext}
+	hint.Update(&l)
+
+	// Пока игрок стоит на месте, решатель не запускается
+	sentinel := SolveResult{Moves: []Direction{Left}}
+	hint.Result = sentinel
+	hint.Update(&l)
+	if !reflect.DeepEqual(hint.Result, sentinel) {
+		t.Fatalf("Update() without a move recomputed the hint: %+v", hint.Result)
+	}
+
+	// Другой уровень с тем же зерном тоже получает новое решение
+	other := NewLevel(l.Size)
+	hint.Update(&other)
+	if reflect.DeepEqual(hint.Result, sentinel) {
+		t.Errorf("Update() kept the hint of the previous level")
+	}
+
+	// После хода подсказка считается заново
+	hint.Result = sentinel
+	if !other.TryMove(Right) && !other.TryMove(Down) {
+		t.Fatal("no move from the start")
+	}
+	hint.Update(&other)
+	if want := other.SolveFrom(other.Player); !reflect.DeepEqual(hint.Result, want) {
+		t.Errorf("hint after a move = %+v, want %+v", hint.Result, want)
+	}
+}
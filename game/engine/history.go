@@ -0,0 +1,62 @@
+package engine
+
+// MoveRecord запись о принятом ходе
+type MoveRecord struct {
+	Dir    Direction
+	Before Player // состояние игрока до хода
+}
+
+// TryMove делает ход, если он допустим, и записывает его в историю
+func (l *Level) TryMove(dir Direction) bool {
+	next, ok := l.NextState(l.Player, dir)
+	if !ok {
+		return false
+	}
+
+	l.History = append(l.History, MoveRecord{Dir: dir, Before: l.Player})
+	l.Future = nil // новый ход отменяет возможность повтора
+	l.Player = next
+	l.Won = l.CheckWin()
+	return true
+}
+
+// Undo отменяет последний ход, восстанавливая позицию и ориентацию кубика
+func (l *Level) Undo() bool {
+	if len(l.History) == 0 {
+		return false
+	}
+
+	last := l.History[len(l.History)-1]
+	l.History = l.History[:len(l.History)-1]
+	l.Future = append(l.Future, last)
+	l.Player = last.Before
+	l.Won = l.CheckWin()
+	return true
+}
+
+// Redo повторяет последний отмененный ход
+func (l *Level) Redo() bool {
+	if len(l.Future) == 0 {
+		return false
+	}
+
+	last := l.Future[len(l.Future)-1]
+	next, ok := l.NextState(l.Player, last.Dir)
+	if !ok {
+		return false
+	}
+	l.Future = l.Future[:len(l.Future)-1]
+	l.History = append(l.History, MoveRecord{Dir: last.Dir, Before: l.Player})
+	l.Player = next
+	l.Won = l.CheckWin()
+	return true
+}
+
+// Restart возвращает игрока на старт с начальной ориентацией кубика,
+// сохраняя лабиринт и финиш
+func (l *Level) Restart() {
+	l.Player = l.StartPlayer()
+	l.History = nil
+	l.Future = nil
+	l.Won = false
+}
@@ -0,0 +1,66 @@
+package engine
+
+import "testing"
+
+func TestTryMove(t *testing.T) {
+	l := parseTestLevel(t, "finish 6\nS.F\n")
+	if l.TryMove(Up) || len(l.History) != 0 {
+		t.Fatalf("TryMove(Up) into the border accepted, history %v", l.History)
+	}
+	if !l.TryMove(Right) || l.Player.X != 1 || len(l.History) != 1 || l.Won {
+		t.Fatalf("after TryMove(Right): player %+v, history %v, won %v", l.Player, l.History, l.Won)
+	}
+	if !l.TryMove(Right) || !l.Won {
+		t.Errorf("TryMove onto the finish with 6 on top: player %+v, won %v", l.Player, l.Won)
+	}
+}
+
+func TestUndoRedo(t *testing.T) {
+	l := parseTestLevel(t, "finish 1\nS..\n..F\n")
+	start := l.Player
+	l.TryMove(Right)
+	afterRight := l.Player
+	l.TryMove(Down)
+	afterDown := l.Player
+
+	steps := []struct {
+		name    string
+		do      func() bool
+		ok      bool
+		want    Player
+		history int
+		future  int
+	}{
+		{"undo down", l.Undo, true, afterRight, 1, 1},
+		{"redo down", l.Redo, true, afterDown, 2, 0},
+		{"redo nothing", l.Redo, false, afterDown, 2, 0},
+		{"undo down again", l.Undo, true, afterRight, 1, 1},
+		{"undo right", l.Undo, true, start, 0, 2},
+		{"undo nothing", l.Undo, false, start, 0, 2},
+		{"redo right", l.Redo, true, afterRight, 1, 1},
+	}
+	for _, s := range steps {
+		if ok := s.do(); ok != s.ok || l.Player != s.want || len(l.History) != s.history || len(l.Future) != s.future {
+			t.Fatalf("%s: ok %v, player %+v, %d moves, %d undone; want %v, %+v, %d, %d",
+				s.name, ok, l.Player, len(l.History), len(l.Future), s.ok, s.want, s.history, s.future)
+		}
+	}
+
+	// Новый ход отменяет возможность повтора
+	l.TryMove(Left)
+	if len(l.Future) != 0 || l.Redo() {
+		t.Errorf("redo after a new move: %d undone moves left", len(l.Future))
+	}
+}
+
+func TestRestart(t *testing.T) {
+	// Старт не в углу: перезапуск возвращает именно на него
+	l := parseTestLevel(t, "finish 6\n..S.F\n.....\n")
+	l.TryMove(Right)
+	l.TryMove(Right)
+	l.Undo()
+	l.Restart()
+	if want := (Player{X: 2, Y: 0, Die: NewDie()}); l.Player != want || len(l.History) != 0 || len(l.Future) != 0 || l.Won {
+		t.Errorf("after Restart: player %+v, %d moves, %d undone, won %v", l.Player, len(l.History), len(l.Future), l.Won)
+	}
+}
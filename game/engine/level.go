@@ -0,0 +1,191 @@
+// Package engine содержит правила KubeGame: кубик, уровень, генерацию
+// лабиринтов, решатель и историю ходов. Пакет не зависит от raylib,
+// поэтому его можно использовать в инструментах без графического окна.
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Cell представляет клетку лабиринта
+type Cell struct {
+	X, Y      int
+	Visited   bool
+	IsWall    bool
+	Kind      CellKind // особая клетка пола
+	Number    int      // число для CellLock
+	Forbidden FaceSet  // запрещенные числа для CellForbid
+
+	// PairX, PairY парная клетка телепорта
+	PairX, PairY int
+}
+
+// LevelSize размер уровня
+type LevelSize struct {
+	Width, Height        int
+	MinWidth, MaxWidth   int
+	MinHeight, MaxHeight int
+	Seed                 int64 // 0 означает случайное зерно
+	Algorithm            MazeAlgorithm
+	Target               DifficultyTarget
+	Teleports            int // число пар телепортов, которые расставит генератор
+}
+
+// Наименьший размер уровня, который умеют строить генераторы лабиринтов
+const (
+	MinGeneratedWidth  = 5
+	MinGeneratedHeight = 5
+)
+
+// DefaultLevelSize возвращает размер уровня по умолчанию и допустимые границы размера
+func DefaultLevelSize() LevelSize {
+	return LevelSize{
+		Width:     15,
+		Height:    10,
+		MinWidth:  MinGeneratedWidth,
+		MaxWidth:  50,
+		MinHeight: MinGeneratedHeight,
+		MaxHeight: 40,
+	}
+}
+
+// Validate проверяет, что размер уровня лежит в допустимых границах
+func (s LevelSize) Validate() error {
+	if s.Width < s.MinWidth || s.Width > s.MaxWidth {
+		return fmt.Errorf("ширина %d вне диапазона %d..%d", s.Width, s.MinWidth, s.MaxWidth)
+	}
+	if s.Height < s.MinHeight || s.Height > s.MaxHeight {
+		return fmt.Errorf("высота %d вне диапазона %d..%d", s.Height, s.MinHeight, s.MaxHeight)
+	}
+	if s.Teleports < 0 {
+		return fmt.Errorf("число телепортов не может быть отрицательным: %d", s.Teleports)
+	}
+	return nil
+}
+
+// Player представляет игрока-кубик
+type Player struct {
+	X, Y int
+	Die  Die
+}
+
+// Level представляет уровень игры
+type Level struct {
+	Player Player
+	Start  struct {
+		X, Y int
+		Die  Die // ориентация кубика на старте
+	}
+	Finish struct {
+		X, Y   int
+		Number int
+	}
+	Cells [][]Cell
+	Size  LevelSize
+	Won   bool
+	Seed  int64 // зерно, из которого сгенерирован уровень
+
+	// OptimalMoves длина кратчайшего решения уровня
+	OptimalMoves int
+	Difficulty   Difficulty
+
+	// История ходов для отмены и повтора
+	History []MoveRecord
+	Future  []MoveRecord
+
+	// id номер уровня, свой у каждого созданного или загруженного уровня
+	id uint64
+}
+
+// NewPlayer создает нового игрока
+func NewPlayer(x, y int) Player {
+	return Player{
+		X:   x,
+		Y:   y,
+		Die: NewDie(),
+	}
+}
+
+// StartPlayer возвращает игрока на старте уровня с кубиком в стартовой ориентации
+func (l *Level) StartPlayer() Player {
+	return Player{X: l.Start.X, Y: l.Start.Y, Die: l.Start.Die}
+}
+
+// Move двигает игрока на одну клетку в указанном направлении: кубик
+// перекатывается, а при slide скользит, не меняя ориентацию
+func (p *Player) Move(dx, dy int, dir Direction, slide bool) {
+	p.X += dx
+	p.Y += dy
+	if !slide {
+		p.Die.Roll(dir)
+	}
+}
+
+// IsValidMove проверяет, можно ли войти в клетку (x, y) с кубиком die,
+// уже перекатившимся на эту клетку
+func (l *Level) IsValidMove(x, y int, die Die) bool {
+	if !l.isOpen(x, y) {
+		return false
+	}
+
+	// Особые клетки пропускают не любую ориентацию кубика
+	return l.Cells[y][x].Accepts(die)
+}
+
+// isOpen проверяет, что клетка внутри сетки и не является стеной
+func (l *Level) isOpen(x, y int) bool {
+	return l.inBounds(x, y) && !l.Cells[y][x].IsWall
+}
+
+// NewLevel создает новый уровень с лабиринтом
+// Один и тот же size.Seed всегда дает один и тот же уровень.
+// Если задан size.Target, лабиринт подгоняется под желаемую сложность
+func NewLevel(size LevelSize) Level {
+	seed := size.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	// Меньшие уровни генераторы не строят: размер берется из загруженного
+	// уровня или задан вручную, поэтому подтягиваем его до минимума
+	size.Width = max(size.Width, MinGeneratedWidth)
+	size.Height = max(size.Height, MinGeneratedHeight)
+
+	l := Level{}
+	l.Size = size
+	l.Seed = seed
+	l.id = lastLevelID.Add(1)
+
+	// Создаем игрока на старте в левом верхнем углу
+	l.Start.Die = NewDie()
+	l.Player = l.StartPlayer()
+
+	// Устанавливаем финиш в правом нижнем углу
+	l.Finish.X = size.Width - 1
+	l.Finish.Y = size.Height - 1
+	l.Finish.Number = rng.Intn(6) + 1 // случайное число от 1 до 6
+
+	// Генерируем проходимый лабиринт и подгоняем его под желаемую сложность
+	l.generateSolvable(rng)
+	if !size.Target.Matches(l.Difficulty) {
+		l.fitDifficulty(rng)
+	}
+
+	l.Won = false
+	return l
+}
+
+// CheckWin проверяет условие победы
+func (l *Level) CheckWin() bool {
+	return l.IsWinState(l.Player)
+}
+
+// IsWinState проверяет, является ли состояние игрока победным
+func (l *Level) IsWinState(p Player) bool {
+	return p.X == l.Finish.X &&
+		p.Y == l.Finish.Y &&
+		p.Die.CurrentTop == l.Finish.Number
+}
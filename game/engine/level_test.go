@@ -0,0 +1,185 @@
+package engine
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// parseTestLevel разбирает уровень в текстовом формате или прерывает тест
+func parseTestLevel(t *testing.T, text string) Level {
+	t.Helper()
+	l, err := ParseLevel(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("ParseLevel: %v", err)
+	}
+	return l
+}
+
+// sameLevel сравнивает то, что сохраняется во всех форматах уровня:
+// сетку с особыми клетками, старт и финиш
+func sameLevel(t *testing.T, got, want *Level) {
+	t.Helper()
+	if got.Size.Width != want.Size.Width || got.Size.Height != want.Size.Height {
+		t.Fatalf("size %dx%d, want %dx%d", got.Size.Width, got.Size.Height, want.Size.Width, want.Size.Height)
+	}
+	if got.Start != want.Start {
+		t.Errorf("start %+v, want %+v", got.Start, want.Start)
+	}
+	if got.Finish != want.Finish {
+		t.Errorf("finish %+v, want %+v", got.Finish, want.Finish)
+	}
+	for y := range want.Cells {
+		for x, w := range want.Cells[y] {
+			g := got.Cells[y][x]
+			if g.IsWall != w.IsWall || g.Kind != w.Kind || g.Number != w.Number ||
+				g.Forbidden != w.Forbidden || g.PairX != w.PairX || g.PairY != w.PairY {
+				t.Errorf("cell (%d,%d) = %+v, want %+v", x, y, g, w)
+			}
+		}
+	}
+}
+
+// testLevels уровни для проверки форматов
+var testLevels = map[string]string{
+	"plain": `finish 6
+S.#
+#.#
+#.F
+`,
+	"die and seed": `; кубик на старте повернут
+finish 2
+die 2 5 6 1 3 4
+seed 7
+..#.F
+S...#
+`,
+	"locks": `finish 3
+S.2.F
+.#5#.
+`,
+	"under markers": `finish 5
+tile 0 0 lock 1
+tile 3 0 lock 5
+S..F
+`,
+	"forbids": `finish 3
+forbid 1 1 1 6
+forbid 0 0 2
+S.a.F
+.e#..
+`,
+	"rotators": `finish 4
+S)..
+.(#F
+`,
+	"rotate under finish": `finish 1
+tile 3 0 rotate-ccw
+forbid 0 0 6
+S..F
+`,
+	"ice": `finish 2
+S~~.
+~#~F
+`,
+	"ice under start": `finish 1
+tile 0 0 ice
+S..F
+`,
+	"teleports": `finish 5
+teleport 2 1 4 1
+teleport 0 1 1 1 reset
+S..#F
+...#.
+`,
+}
+
+// forEachAlgorithm запускает подтест для каждого алгоритма лабиринта
+func forEachAlgorithm(t *testing.T, test func(t *testing.T, a MazeAlgorithm)) {
+	for a := MazeAlgorithm(0); ; a++ {
+		t.Run(a.String(), func(t *testing.T) { test(t, a) })
+		if a.Next() == 0 {
+			break
+		}
+	}
+}
+
+func TestNewLevelSolvable(t *testing.T) {
+	forEachAlgorithm(t, func(t *testing.T, a MazeAlgorithm) {
+		for i := 0; i < 10; i++ {
+			l := NewLevel(LevelSize{Width: 15, Height: 10, Algorithm: a})
+			result := l.Solve()
+			if !result.Solvable {
+				t.Fatalf("level %d is not solvable", i)
+			}
+			if l.OptimalMoves != len(result.Moves) {
+				t.Errorf("OptimalMoves = %d, want %d", l.OptimalMoves, len(result.Moves))
+			}
+		}
+	})
+}
+
+func TestNewLevelDeterministic(t *testing.T) {
+	forEachAlgorithm(t, func(t *testing.T, a MazeAlgorithm) {
+		size := LevelSize{
+			Width: 15, Height: 10, Seed: 42, Algorithm: a, Teleports: 1,
+			Target: DifficultyTarget{Band: BandMedium},
+		}
+		first, second := NewLevel(size), NewLevel(size)
+		if !reflect.DeepEqual(first.Cells, second.Cells) || first.Finish != second.Finish {
+			t.Error("same seed gives different levels")
+		}
+		if first.Difficulty != second.Difficulty {
+			t.Errorf("difficulty %+v, then %+v", first.Difficulty, second.Difficulty)
+		}
+		if first.Seed != 42 {
+			t.Errorf("Seed = %d, want 42", first.Seed)
+		}
+	})
+}
+
+func TestOpenFieldSolvable(t *testing.T) {
+	// Лабиринт, на котором кончились попытки генерации: финиш достижим
+	// по узкому коридору только с пятеркой сверху, и открытие внутренних
+	// клеток этого не меняет
+	l := parseTestLevel(t, "finish 1\nS.##\n#..#\n.#.F\n")
+	if l.Solve().Solvable {
+		t.Fatal("test level is already solvable")
+	}
+
+	result := l.openField(rand.New(rand.NewSource(1)))
+	if !result.Solvable {
+		t.Fatalf("level with finish %d is not solvable after opening the field", l.Finish.Number)
+	}
+	if !reflect.DeepEqual(result, l.Solve()) {
+		t.Errorf("openField() = %+v, Solve() = %+v", result, l.Solve())
+	}
+}
+
+func TestNewLevelClampsSize(t *testing.T) {
+	tests := []struct{ width, height int }{{0, 0}, {4, 2}, {5, 5}, {3, 8}}
+	for _, tt := range tests {
+		l := NewLevel(LevelSize{Width: tt.width, Height: tt.height, Seed: 1})
+		if l.Size.Width < MinGeneratedWidth || l.Size.Height < MinGeneratedHeight {
+			t.Errorf("NewLevel(%dx%d) size %dx%d", tt.width, tt.height, l.Size.Width, l.Size.Height)
+		}
+		if !l.Solve().Solvable {
+			t.Errorf("NewLevel(%dx%d) is not solvable", tt.width, tt.height)
+		}
+	}
+}
+
+func TestNewLevelTeleportsSolvable(t *testing.T) {
+	// Телепорты на маленьком поле занимают заметную часть пола,
+	// но уровень все равно должен решаться
+	for seed := int64(1); seed <= 20; seed++ {
+		l := NewLevel(LevelSize{Width: 5, Height: 5, Seed: seed, Teleports: 3})
+		if !l.Solve().Solvable {
+			t.Errorf("seed %d: level with teleports is not solvable", seed)
+		}
+		if err := l.checkTeleports(); err != nil {
+			t.Errorf("seed %d: %v", seed, err)
+		}
+	}
+}
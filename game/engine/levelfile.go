@@ -0,0 +1,413 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Текстовый формат уровня:
+//
+//	; комментарий
+//	finish 4            число, которое должно оказаться сверху на финише
+//	die 1 6 2 5 3 4     необязательно: Top Bottom Front Back Left Right на старте
+//	seed 42             необязательно: зерно, из которого получен уровень
+//	forbid 3 1 1 6      необязательно: в клетку (3, 1) нельзя закатить 1 или 6 сверху
+//	teleport 2 0 4 1    необязательно: пара телепортов (2, 0) и (4, 1)
+//	teleport 0 1 0 2 reset
+//	                    то же, но после переноса кубик в ориентации NewDie
+//	tile 4 2 lock 3     необязательно: особая клетка под 'S' или 'F', которую
+//	                    не видно в сетке (lock N, rotate-cw, rotate-ccw, ice)
+//	S..#.
+//	.#.f.
+//	..)#F
+//
+// В сетке '#' стена, '.' пол, 'S' старт, 'F' финиш,
+// цифры 1-6 замки: клетки, на которые можно закатить кубик только этим числом вверх,
+// буквы a-f запреты: клетки, на которые нельзя закатить кубик числом 1-6 вверх
+// (a — 1, ..., f — 6). Запрет нескольких чисел задается директивой forbid.
+// ')' и '(' поворотные клетки: кубик на них поворачивается на месте
+// по часовой стрелке и против нее, '~' лед: по нему кубик скользит не перекатываясь,
+// пока не упрется в препятствие или не выедет на клетку без льда.
+// Телепорты задаются только директивой teleport.
+
+// Символы сетки уровня
+const (
+	TileWall   = '#'
+	TileFloor  = '.'
+	TileStart  = 'S'
+	TileFinish = 'F'
+	TileForbid = 'a' // запрет числа 1, 'b' запрет 2 и так далее до 'f'
+
+	TileRotateCW  = ')'
+	TileRotateCCW = '('
+	TileIce       = '~'
+)
+
+// ParseLevel читает уровень в текстовом формате
+func ParseLevel(r io.Reader) (Level, error) {
+	l := Level{}
+	l.Start.Die = NewDie()
+	var rows []string
+	var directives []tileDirective
+	startFound, finishFound := false, false
+
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		var err error
+		switch fields[0] {
+		case "finish":
+			err = parseFinish(&l, fields[1:])
+		case "die":
+			err = parseDie(&l, fields[1:])
+		case "seed":
+			err = parseSeed(&l, fields[1:])
+		case "forbid":
+			var tiles []Cell
+			tiles, err = parseForbid(fields[1:])
+			directives = append(directives, tileDirective{tiles, lineNumber})
+		case "teleport":
+			var tiles []Cell
+			tiles, err = parseTeleport(fields[1:])
+			directives = append(directives, tileDirective{tiles, lineNumber})
+		case "tile":
+			var tiles []Cell
+			tiles, err = parseTile(fields[1:])
+			directives = append(directives, tileDirective{tiles, lineNumber})
+		default:
+			if len(rows) > 0 && len(line) != len(rows[0]) {
+				err = fmt.Errorf("длина строки %d, ожидалась %d", len(line), len(rows[0]))
+				break
+			}
+			for x, ch := range line {
+				switch ch {
+				case TileWall, TileFloor:
+				case '1', '2', '3', '4', '5', '6':
+				case 'a', 'b', 'c', 'd', 'e', 'f':
+				case TileRotateCW, TileRotateCCW, TileIce:
+				case TileStart:
+					if startFound {
+						err = fmt.Errorf("второй старт в клетке (%d,%d)", x, len(rows))
+					}
+					startFound = true
+					l.Start.X, l.Start.Y = x, len(rows)
+				case TileFinish:
+					if finishFound {
+						err = fmt.Errorf("второй финиш в клетке (%d,%d)", x, len(rows))
+					}
+					finishFound = true
+					l.Finish.X, l.Finish.Y = x, len(rows)
+				default:
+					err = fmt.Errorf("неизвестный символ %q", ch)
+				}
+			}
+			rows = append(rows, line)
+		}
+		if err != nil {
+			return Level{}, fmt.Errorf("строка %d: %w", lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Level{}, err
+	}
+
+	switch {
+	case len(rows) == 0:
+		return Level{}, fmt.Errorf("в уровне нет сетки")
+	case !startFound:
+		return Level{}, fmt.Errorf("в уровне нет старта %q", TileStart)
+	case !finishFound:
+		return Level{}, fmt.Errorf("в уровне нет финиша %q", TileFinish)
+	case l.Finish.Number == 0:
+		return Level{}, fmt.Errorf("не задано число финиша (finish N)")
+	}
+
+	l.Size.Width, l.Size.Height = len(rows[0]), len(rows)
+	l.Cells = make([][]Cell, len(rows))
+	for y, row := range rows {
+		l.Cells[y] = make([]Cell, len(row))
+		for x := range row {
+			l.Cells[y][x] = Cell{X: x, Y: y, IsWall: row[x] == TileWall}
+			switch {
+			case row[x] >= '1' && row[x] <= '6':
+				l.Cells[y][x].Kind = CellLock
+				l.Cells[y][x].Number = int(row[x] - '0')
+			case row[x] >= TileForbid && row[x] < TileForbid+6:
+				l.Cells[y][x].Kind = CellForbid
+				l.Cells[y][x].Forbidden = NewFaceSet(int(row[x]-TileForbid) + 1)
+			case row[x] == TileRotateCW:
+				l.Cells[y][x].Kind = CellRotateCW
+			case row[x] == TileRotateCCW:
+				l.Cells[y][x].Kind = CellRotateCCW
+			case row[x] == TileIce:
+				l.Cells[y][x].Kind = CellIce
+			}
+		}
+	}
+
+	for _, d := range directives {
+		for _, tile := range d.tiles {
+			if err := l.setTile(tile); err != nil {
+				return Level{}, fmt.Errorf("строка %d: %w", d.line, err)
+			}
+		}
+	}
+	if err := l.checkTeleports(); err != nil {
+		return Level{}, err
+	}
+
+	l.prepare()
+	return l, nil
+}
+
+// parseFinish разбирает директиву finish N
+func parseFinish(l *Level, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("ожидалось finish N")
+	}
+	number, err := strconv.Atoi(args[0])
+	if err != nil || number < 1 || number > 6 {
+		return fmt.Errorf("число финиша должно быть от 1 до 6, получено %q", args[0])
+	}
+	l.Finish.Number = number
+	return nil
+}
+
+// parseDie разбирает директиву die Top Bottom Front Back Left Right
+func parseDie(l *Level, args []string) error {
+	if len(args) != 6 {
+		return fmt.Errorf("ожидалось die Top Bottom Front Back Left Right")
+	}
+	var faces [6]int
+	for i, arg := range args {
+		number, err := strconv.Atoi(arg)
+		if err != nil {
+			return fmt.Errorf("грань кубика %q не число", arg)
+		}
+		faces[i] = number
+	}
+	die := Die{
+		Top: faces[0], Bottom: faces[1],
+		Front: faces[2], Back: faces[3],
+		Left: faces[4], Right: faces[5],
+		CurrentTop: faces[0],
+	}
+	if die.Orientation() < 0 {
+		return fmt.Errorf("кубик %v нельзя получить перекатыванием", args)
+	}
+	l.Start.Die = die
+	return nil
+}
+
+// tileDirective особые клетки из директивы, которые ставятся после разбора сетки
+type tileDirective struct {
+	tiles []Cell
+	line  int
+}
+
+// parseForbid разбирает директиву forbid X Y N...
+func parseForbid(args []string) ([]Cell, error) {
+	if len(args) < 3 {
+		return nil, fmt.Errorf("ожидалось forbid X Y N...")
+	}
+	values, err := parseInts(args)
+	if err != nil {
+		return nil, err
+	}
+	return []Cell{{X: values[0], Y: values[1], Kind: CellForbid, Forbidden: NewFaceSet(values[2:]...)}}, nil
+}
+
+// parseTeleport разбирает директиву teleport X1 Y1 X2 Y2 [reset]
+func parseTeleport(args []string) ([]Cell, error) {
+	kind := CellTeleport
+	if len(args) == 5 && args[4] == "reset" {
+		kind, args = CellTeleportReset, args[:4]
+	}
+	if len(args) != 4 {
+		return nil, fmt.Errorf("ожидалось teleport X1 Y1 X2 Y2 [reset]")
+	}
+	v, err := parseInts(args)
+	if err != nil {
+		return nil, err
+	}
+	return []Cell{
+		{X: v[0], Y: v[1], Kind: kind, PairX: v[2], PairY: v[3]},
+		{X: v[2], Y: v[3], Kind: kind, PairX: v[0], PairY: v[1]},
+	}, nil
+}
+
+// parseTile разбирает директиву tile X Y KIND [N] для клеток, которые
+// в сетке обозначаются символом и не видны под стартом и финишем;
+// запреты и телепорты задаются своими директивами
+func parseTile(args []string) ([]Cell, error) {
+	if len(args) < 3 {
+		return nil, fmt.Errorf("ожидалось tile X Y KIND [N]")
+	}
+	var kind CellKind
+	if err := kind.UnmarshalText([]byte(args[2])); err != nil {
+		return nil, err
+	}
+	usage, numbers := "tile X Y "+kind.String(), 0
+	switch kind {
+	case CellLock:
+		usage, numbers = usage+" N", 1
+	case CellRotateCW, CellRotateCCW, CellIce:
+	default:
+		return nil, fmt.Errorf("клетку %s нельзя задать директивой tile", kind)
+	}
+	if len(args) != 3+numbers {
+		return nil, fmt.Errorf("ожидалось %s", usage)
+	}
+	values, err := parseInts(append(args[:2:2], args[3:]...))
+	if err != nil {
+		return nil, err
+	}
+	tile := Cell{X: values[0], Y: values[1], Kind: kind}
+	if kind == CellLock {
+		tile.Number = values[2]
+	}
+	return []Cell{tile}, nil
+}
+
+// parseInts разбирает аргументы директивы как целые числа
+func parseInts(args []string) ([]int, error) {
+	var values []int
+	for _, arg := range args {
+		value, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, fmt.Errorf("%q не число", arg)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// parseSeed разбирает директиву seed N
+func parseSeed(l *Level, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("ожидалось seed N")
+	}
+	seed, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("зерно %q не число", args[0])
+	}
+	l.Seed = seed
+	l.Size.Seed = seed
+	return nil
+}
+
+// prepare ставит игрока на старт, выдает номер и оценивает загруженный уровень
+func (l *Level) prepare() {
+	l.id = lastLevelID.Add(1)
+	l.Player = l.StartPlayer()
+	l.Won = false
+	l.History, l.Future = nil, nil
+	l.Difficulty = l.RateDifficulty()
+	l.OptimalMoves = l.Difficulty.OptimalMoves
+}
+
+// LoadLevel загружает уровень из текстового файла
+func LoadLevel(path string) (Level, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Level{}, err
+	}
+	defer f.Close()
+
+	l, err := ParseLevel(f)
+	if err != nil {
+		return Level{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return l, nil
+}
+
+// WriteLevel записывает уровень в текстовом формате
+func WriteLevel(w io.Writer, l *Level) error {
+	var b strings.Builder
+	b.WriteString("; KubeGame level\n")
+	fmt.Fprintf(&b, "finish %d\n", l.Finish.Number)
+	if d := l.Start.Die; d != NewDie() {
+		fmt.Fprintf(&b, "die %d %d %d %d %d %d\n", d.Top, d.Bottom, d.Front, d.Back, d.Left, d.Right)
+	}
+	if l.Seed != 0 {
+		fmt.Fprintf(&b, "seed %d\n", l.Seed)
+	}
+	for _, tile := range l.Tiles() {
+		onMarker := tile.X == l.Start.X && tile.Y == l.Start.Y || tile.X == l.Finish.X && tile.Y == l.Finish.Y
+		if tile.Kind == CellForbid && (len(tile.Forbidden.Numbers()) > 1 || onMarker) {
+			fmt.Fprintf(&b, "forbid %d %d", tile.X, tile.Y)
+			for _, n := range tile.Forbidden.Numbers() {
+				fmt.Fprintf(&b, " %d", n)
+			}
+			b.WriteByte('\n')
+		}
+		// Под стартом и финишем клетку не видно в сетке, поэтому она пишется директивой
+		if onMarker && (tile.Kind == CellLock || tile.Kind == CellRotateCW || tile.Kind == CellRotateCCW || tile.Kind == CellIce) {
+			fmt.Fprintf(&b, "tile %d %d %s", tile.X, tile.Y, tile.Kind)
+			if tile.Kind == CellLock {
+				fmt.Fprintf(&b, " %d", tile.Number)
+			}
+			b.WriteByte('\n')
+		}
+		// Пара телепортов записывается один раз, от клетки, которая идет раньше в сетке
+		if tile.IsTeleport() && (tile.Y < tile.PairY || tile.Y == tile.PairY && tile.X < tile.PairX) {
+			fmt.Fprintf(&b, "teleport %d %d %d %d", tile.X, tile.Y, tile.PairX, tile.PairY)
+			if tile.Kind == CellTeleportReset {
+				b.WriteString(" reset")
+			}
+			b.WriteByte('\n')
+		}
+	}
+
+	for y, row := range l.Cells {
+		for x, cell := range row {
+			switch {
+			case x == l.Start.X && y == l.Start.Y:
+				b.WriteByte(TileStart)
+			case x == l.Finish.X && y == l.Finish.Y:
+				b.WriteByte(TileFinish)
+			case cell.IsWall:
+				b.WriteByte(TileWall)
+			case cell.Kind == CellLock:
+				b.WriteByte(byte('0' + cell.Number))
+			case cell.Kind == CellForbid:
+				// Несколько чисел записаны директивой forbid, в сетке первое из них
+				b.WriteByte(byte(TileForbid + cell.Forbidden.Numbers()[0] - 1))
+			case cell.Kind == CellRotateCW:
+				b.WriteByte(TileRotateCW)
+			case cell.Kind == CellRotateCCW:
+				b.WriteByte(TileRotateCCW)
+			case cell.Kind == CellIce:
+				b.WriteByte(TileIce)
+			default:
+				b.WriteByte(TileFloor)
+			}
+		}
+		b.WriteByte('\n')
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// SaveLevel сохраняет уровень в текстовый файл
+func SaveLevel(path string, l *Level) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteLevel(f, l); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteLevelRoundTrip(t *testing.T) {
+	for name, text := range testLevels {
+		t.Run(name, func(t *testing.T) {
+			want := parseTestLevel(t, text)
+			var b strings.Builder
+			if err := WriteLevel(&b, &want); err != nil {
+				t.Fatalf("WriteLevel: %v", err)
+			}
+			got := parseTestLevel(t, b.String())
+			sameLevel(t, &got, &want)
+			if got.Seed != want.Seed {
+				t.Errorf("Seed = %d, want %d", got.Seed, want.Seed)
+			}
+		})
+	}
+}
+
+func TestWriteGeneratedLevelRoundTrip(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		want := NewLevel(LevelSize{Width: 12, Height: 9, Seed: seed, Teleports: 2})
+		var b strings.Builder
+		if err := WriteLevel(&b, &want); err != nil {
+			t.Fatalf("seed %d: WriteLevel: %v", seed, err)
+		}
+		got := parseTestLevel(t, b.String())
+		sameLevel(t, &got, &want)
+	}
+}
+
+func TestParseLevelErrors(t *testing.T) {
+	tests := []struct {
+		name, text, err string
+	}{
+		{"no grid", "finish 1\n", "нет сетки"},
+		{"no start", "finish 1\n..F\n", "нет старта"},
+		{"no finish", "finish 1\nS..\n", "нет финиша"},
+		{"no finish number", "S.F\n", "не задано число финиша"},
+		{"finish number", "finish 7\nS.F\n", "от 1 до 6"},
+		{"two starts", "finish 1\nSSF\n", "второй старт"},
+		{"ragged grid", "finish 1\nS.F\n..\n", "длина строки"},
+		{"unknown char", "finish 1\nS?F\n", "неизвестный символ"},
+		{"impossible die", "finish 1\ndie 1 1 1 1 1 1\nS.F\n", "нельзя получить"},
+		{"seed", "finish 1\nseed x\nS.F\n", "не число"},
+		{"tile kind", "finish 1\ntile 1 0 floor\nS.F\n", "директивой tile"},
+		{"tile forbid", "finish 1\ntile 1 0 forbid\nS.F\n", "директивой tile"},
+		{"tile teleport", "finish 1\ntile 1 0 teleport\nS.F\n", "директивой tile"},
+		{"tile unknown kind", "finish 1\ntile 1 0 lava\nS.F\n", "неизвестный вид"},
+		{"tile lock number", "finish 1\ntile 1 0 lock\nS.F\n", "tile X Y lock N"},
+		{"tile args", "finish 1\ntile 1 0 rotate-cw 2\nS.F\n", "tile X Y rotate-cw"},
+		{"tile ice args", "finish 1\ntile 1 0 ice 2\nS.F\n", "tile X Y ice"},
+		{"tile lock range", "finish 1\ntile 1 0 lock 7\nS.F\n", "от 1 до 6"},
+		{"tile wall", "finish 1\ntile 1 0 lock 2\nS#F\n", "стена"},
+		{"tile outside", "finish 1\ntile 5 0 lock 2\nS.F\n", "вне уровня"},
+		{"tile not a number", "finish 1\ntile x 0 lock 2\nS.F\n", "не число"},
+		{"forbid args", "finish 1\nforbid 1 0\nS.F\n", "ожидалось forbid"},
+		{"forbid number", "finish 1\nforbid 1 0 7\nS.F\n", "от 1 до 6"},
+		{"forbid wall", "finish 1\nforbid 1 0 2\nS#F\n", "стена"},
+		{"forbid outside", "finish 1\nforbid 5 0 2\nS.F\n", "вне уровня"},
+		{"teleport args", "finish 1\nteleport 1 0 2\nS.F\n", "ожидалось teleport"},
+		{"unpaired teleport", "finish 1\nteleport 1 0 1 0\nS.F\n", "неверная пара"},
+		{"teleport on wall", "finish 1\nteleport 1 0 0 1\nS.F\n#..\n", "стена"},
+		{"teleport on finish", "finish 1\nteleport 1 0 2 0\nS.F\n", "на финише"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseLevel(strings.NewReader(tt.text))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ParseLevel error %v, want containing %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// LevelJSONVersion версия JSON-схемы уровня. Ее нужно увеличивать при
+// несовместимых изменениях схемы; старые версии по возможности читаются
+//
+//	1 — стены, старт, финиш, игрок
+//	2 — особые клетки в tiles
+const LevelJSONVersion = 2
+
+// dieJSON кубик в JSON: все шесть граней, число сверху совпадает с top
+type dieJSON struct {
+	Top    int `json:"top"`
+	Bottom int `json:"bottom"`
+	Front  int `json:"front"`
+	Back   int `json:"back"`
+	Left   int `json:"left"`
+	Right  int `json:"right"`
+}
+
+// playerJSON позиция игрока и его кубик в JSON
+type playerJSON struct {
+	X   int `json:"x"`
+	Y   int `json:"y"`
+	Die Die `json:"die"`
+}
+
+// tileJSON особая клетка в JSON
+type tileJSON struct {
+	X         int        `json:"x"`
+	Y         int        `json:"y"`
+	Kind      CellKind   `json:"kind"`
+	Number    int        `json:"number,omitempty"`
+	Forbidden FaceSet    `json:"forbidden,omitempty"`
+	Pair      *pointJSON `json:"pair,omitempty"` // парная клетка телепорта
+}
+
+// pointJSON координаты клетки в JSON
+type pointJSON struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// levelJSON уровень в JSON
+type levelJSON struct {
+	Version int        `json:"version"`
+	Width   int        `json:"width"`
+	Height  int        `json:"height"`
+	Seed    int64      `json:"seed,omitempty"`
+	Walls   [][]bool   `json:"walls"`
+	Tiles   []tileJSON `json:"tiles,omitempty"`
+	Start   Player     `json:"start"`
+	Finish  struct {
+		X      int `json:"x"`
+		Y      int `json:"y"`
+		Number int `json:"number"`
+	} `json:"finish"`
+	Player Player `json:"player"`
+	Won    bool   `json:"won"`
+}
+
+// MarshalJSON записывает кубик со всеми гранями
+func (d Die) MarshalJSON() ([]byte, error) {
+	return json.Marshal(dieJSON{d.Top, d.Bottom, d.Front, d.Back, d.Left, d.Right})
+}
+
+// UnmarshalJSON читает кубик и проверяет, что его можно получить перекатыванием
+func (d *Die) UnmarshalJSON(data []byte) error {
+	var dj dieJSON
+	if err := json.Unmarshal(data, &dj); err != nil {
+		return err
+	}
+	die := Die{
+		Top: dj.Top, Bottom: dj.Bottom,
+		Front: dj.Front, Back: dj.Back,
+		Left: dj.Left, Right: dj.Right,
+		CurrentTop: dj.Top,
+	}
+	if die.Orientation() < 0 {
+		return fmt.Errorf("кубик %+v нельзя получить перекатыванием", dj)
+	}
+	*d = die
+	return nil
+}
+
+// MarshalJSON записывает позицию игрока и его кубик
+func (p Player) MarshalJSON() ([]byte, error) {
+	return json.Marshal(playerJSON{p.X, p.Y, p.Die})
+}
+
+// UnmarshalJSON читает позицию игрока и его кубик
+func (p *Player) UnmarshalJSON(data []byte) error {
+	var pj playerJSON
+	if err := json.Unmarshal(data, &pj); err != nil {
+		return err
+	}
+	*p = Player{X: pj.X, Y: pj.Y, Die: pj.Die}
+	return nil
+}
+
+// MarshalJSON записывает уровень в JSON-схеме версии LevelJSONVersion
+func (l Level) MarshalJSON() ([]byte, error) {
+	lj := levelJSON{
+		Version: LevelJSONVersion,
+		Width:   l.Size.Width,
+		Height:  l.Size.Height,
+		Seed:    l.Seed,
+		Walls:   make([][]bool, len(l.Cells)),
+		Start:   l.StartPlayer(),
+		Player:  l.Player,
+		Won:     l.Won,
+	}
+	for y, row := range l.Cells {
+		lj.Walls[y] = make([]bool, len(row))
+		for x, cell := range row {
+			lj.Walls[y][x] = cell.IsWall
+		}
+	}
+	for _, tile := range l.Tiles() {
+		tj := tileJSON{
+			X: tile.X, Y: tile.Y, Kind: tile.Kind,
+			Number: tile.Number, Forbidden: tile.Forbidden,
+		}
+		if tile.IsTeleport() {
+			tj.Pair = &pointJSON{tile.PairX, tile.PairY}
+		}
+		lj.Tiles = append(lj.Tiles, tj)
+	}
+	lj.Finish.X, lj.Finish.Y, lj.Finish.Number = l.Finish.X, l.Finish.Y, l.Finish.Number
+	return json.Marshal(lj)
+}
+
+// UnmarshalJSON читает уровень, проверяет его и заново оценивает сложность
+func (l *Level) UnmarshalJSON(data []byte) error {
+	var lj levelJSON
+	if err := json.Unmarshal(data, &lj); err != nil {
+		return err
+	}
+	if lj.Version < 1 || lj.Version > LevelJSONVersion {
+		return fmt.Errorf("неподдерживаемая версия уровня %d (поддерживается до %d)", lj.Version, LevelJSONVersion)
+	}
+	if lj.Width < 1 || lj.Height < 1 || len(lj.Walls) != lj.Height {
+		return fmt.Errorf("размер уровня %dx%d не совпадает с сеткой", lj.Width, lj.Height)
+	}
+
+	loaded := Level{}
+	loaded.Size.Width, loaded.Size.Height = lj.Width, lj.Height
+	loaded.Size.Seed, loaded.Seed = lj.Seed, lj.Seed
+	loaded.Cells = make([][]Cell, lj.Height)
+	for y, row := range lj.Walls {
+		if len(row) != lj.Width {
+			return fmt.Errorf("строка %d сетки длины %d, ожидалась %d", y, len(row), lj.Width)
+		}
+		loaded.Cells[y] = make([]Cell, lj.Width)
+		for x, wall := range row {
+			loaded.Cells[y][x] = Cell{X: x, Y: y, IsWall: wall}
+		}
+	}
+
+	for _, tj := range lj.Tiles {
+		tile := Cell{
+			X: tj.X, Y: tj.Y, Kind: tj.Kind,
+			Number: tj.Number, Forbidden: tj.Forbidden,
+		}
+		if tj.Pair != nil {
+			tile.PairX, tile.PairY = tj.Pair.X, tj.Pair.Y
+		}
+		if err := loaded.setTile(tile); err != nil {
+			return err
+		}
+	}
+
+	loaded.Start.X, loaded.Start.Y, loaded.Start.Die = lj.Start.X, lj.Start.Y, lj.Start.Die
+	loaded.Finish.X, loaded.Finish.Y, loaded.Finish.Number = lj.Finish.X, lj.Finish.Y, lj.Finish.Number
+	switch {
+	case !loaded.inBounds(lj.Start.X, lj.Start.Y):
+		return fmt.Errorf("старт (%d,%d) вне уровня", lj.Start.X, lj.Start.Y)
+	case !loaded.inBounds(lj.Finish.X, lj.Finish.Y):
+		return fmt.Errorf("финиш (%d,%d) вне уровня", lj.Finish.X, lj.Finish.Y)
+	case !loaded.inBounds(lj.Player.X, lj.Player.Y):
+		return fmt.Errorf("игрок (%d,%d) вне уровня", lj.Player.X, lj.Player.Y)
+	case lj.Start.Die.Orientation() < 0 || lj.Player.Die.Orientation() < 0:
+		return fmt.Errorf("не задан кубик на старте или у игрока")
+	case lj.Finish.Number < 1 || lj.Finish.Number > 6:
+		return fmt.Errorf("число финиша должно быть от 1 до 6, получено %d", lj.Finish.Number)
+	}
+	if err := loaded.checkEndpoints(); err != nil {
+		return err
+	}
+	if err := loaded.checkTeleports(); err != nil {
+		return err
+	}
+
+	loaded.prepare()
+	loaded.Player = lj.Player
+	loaded.Won = lj.Won
+	*l = loaded
+	return nil
+}
+
+// inBounds проверяет, что клетка лежит внутри уровня
+func (l *Level) inBounds(x, y int) bool {
+	return x >= 0 && x < l.Size.Width && y >= 0 && y < l.Size.Height
+}
+
+// checkEndpoints проверяет, что старт и финиш лежат на полу и не совпадают.
+// Координаты уже должны быть проверены inBounds
+func (l *Level) checkEndpoints() error {
+	switch {
+	case l.Cells[l.Start.Y][l.Start.X].IsWall:
+		return fmt.Errorf("старт (%d,%d) в стене", l.Start.X, l.Start.Y)
+	case l.Cells[l.Finish.Y][l.Finish.X].IsWall:
+		return fmt.Errorf("финиш (%d,%d) в стене", l.Finish.X, l.Finish.Y)
+	case l.Start.X == l.Finish.X && l.Start.Y == l.Finish.Y:
+		return fmt.Errorf("старт и финиш в одной клетке (%d,%d)", l.Start.X, l.Start.Y)
+	}
+	return nil
+}
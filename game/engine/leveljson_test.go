@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestLevelJSONRoundTrip(t *testing.T) {
+	for name, text := range testLevels {
+		t.Run(name, func(t *testing.T) {
+			want := parseTestLevel(t, text)
+			want.TryMove(Down)
+			data, err := json.Marshal(want)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			var got Level
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			sameLevel(t, &got, &want)
+			if got.Player != want.Player || got.Seed != want.Seed {
+				t.Errorf("player %+v seed %d, want %+v seed %d", got.Player, got.Seed, want.Player, want.Seed)
+			}
+		})
+	}
+}
+
+func TestLevelJSONErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(map[string]any)
+	}{
+		{"version", func(m map[string]any) { m["version"] = 99 }},
+		{"height", func(m map[string]any) { m["height"] = 7 }},
+		{"finish number", func(m map[string]any) { m["finish"].(map[string]any)["number"] = 0 }},
+		{"player outside", func(m map[string]any) { m["player"].(map[string]any)["x"] = 10 }},
+		{"wall on start", func(m map[string]any) { m["walls"].([]any)[0].([]any)[0] = true }},
+		{"wall on finish", func(m map[string]any) {
+			finish := m["finish"].(map[string]any)
+			x, y := int(finish["x"].(float64)), int(finish["y"].(float64))
+			m["walls"].([]any)[y].([]any)[x] = true
+		}},
+		{"finish on start", func(m map[string]any) {
+			start := m["start"].(map[string]any)
+			m["finish"].(map[string]any)["x"], m["finish"].(map[string]any)["y"] = start["x"], start["y"]
+		}},
+		{"impossible die", func(m map[string]any) {
+			m["start"].(map[string]any)["die"].(map[string]any)["top"] = 6
+		}},
+		{"tile on wall", func(m map[string]any) {
+			m["tiles"] = []any{map[string]any{"x": 2, "y": 0, "kind": "lock", "number": 3}}
+		}},
+		{"lock number", func(m map[string]any) {
+			m["tiles"] = []any{map[string]any{"x": 1, "y": 0, "kind": "lock", "number": 7}}
+		}},
+		{"forbid number", func(m map[string]any) {
+			m["tiles"] = []any{map[string]any{"x": 1, "y": 0, "kind": "forbid", "forbidden": []int{0}}}
+		}},
+		{"forbid nothing", func(m map[string]any) {
+			m["tiles"] = []any{map[string]any{"x": 1, "y": 0, "kind": "forbid"}}
+		}},
+		{"one-way teleport", func(m map[string]any) {
+			m["tiles"] = []any{map[string]any{"x": 1, "y": 0, "kind": "teleport", "pair": map[string]any{"x": 1, "y": 1}}}
+		}},
+		{"unknown tile", func(m map[string]any) {
+			m["tiles"] = []any{map[string]any{"x": 1, "y": 0, "kind": "lava"}}
+		}},
+	}
+	base := parseTestLevel(t, testLevels["plain"])
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _ := json.Marshal(base)
+			var m map[string]any
+			if err := json.Unmarshal(data, &m); err != nil {
+				t.Fatal(err)
+			}
+			tt.edit(m)
+			data, _ = json.Marshal(m)
+			var l Level
+			if err := json.Unmarshal(data, &l); err == nil {
+				t.Error("Unmarshal accepted a broken level")
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package engine
+
+import "math/rand"
+
+const (
+	WallDensity = 0.3
+
+	// MaxGenerationAttempts сколько раз перегенерировать лабиринт,
+	// прежде чем открыть поле целиком
+	MaxGenerationAttempts = 50
+)
+
+// GenerateMaze генерирует лабиринт выбранным в l.Size.Algorithm алгоритмом
+func (l *Level) GenerateMaze(rng *rand.Rand) {
+	// Инициализация клеток
+	l.Cells = make([][]Cell, l.Size.Height)
+	for y := 0; y < l.Size.Height; y++ {
+		l.Cells[y] = make([]Cell, l.Size.Width)
+		for x := 0; x < l.Size.Width; x++ {
+			l.Cells[y][x] = Cell{
+				X:       x,
+				Y:       y,
+				Visited: false,
+				IsWall:  false, // Начинаем со всеми проходимыми клетками
+			}
+		}
+	}
+
+	l.Size.Algorithm.Generator().Generate(l, rng)
+
+	// Убедимся, что старт и финиш проходимы
+	l.Cells[l.Start.Y][l.Start.X].IsWall = false
+	l.Cells[l.Finish.Y][l.Finish.X].IsWall = false
+}
+
+// createGuaranteedPath создает гарантированный путь от старта к финишу
+func (l *Level) createGuaranteedPath(rng *rand.Rand) {
+	// Алгоритм для создания пути
+	// Начинаем от старта и идем к финишу
+	x, y := l.Start.X, l.Start.Y
+	targetX, targetY := l.Finish.X, l.Finish.Y
+
+	// Основное направление движения
+	for x < targetX || y < targetY {
+		// Решаем, двигаться ли вправо или вниз
+		if x < targetX && (y >= targetY || rng.Float64() < 0.5) {
+			// Двигаемся вправо
+			for dx := 0; dx < 2 && x+dx < l.Size.Width; dx++ {
+				l.Cells[y][x+dx].IsWall = false
+			}
+			x++
+		} else if y < targetY {
+			// Двигаемся вниз
+			for dy := 0; dy < 2 && y+dy < l.Size.Height; dy++ {
+				l.Cells[y+dy][x].IsWall = false
+			}
+			y++
+		}
+	}
+}
+
+// EnsureConnectivity проверяет и улучшает связность лабиринта
+func (l *Level) EnsureConnectivity() {
+	// Помечаем все клетки как непосещенные
+	for y := 0; y < l.Size.Height; y++ {
+		for x := 0; x < l.Size.Width; x++ {
+			l.Cells[y][x].Visited = false
+		}
+	}
+
+	// Проверяем доступность от старта
+	queue := []struct{ x, y int }{{l.Start.X, l.Start.Y}}
+	l.Cells[l.Start.Y][l.Start.X].Visited = true
+
+	// BFS для проверки связности
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		// Проверяем соседей
+		directions := []struct{ dx, dy int }{{0, -1}, {0, 1}, {-1, 0}, {1, 0}}
+		for _, dir := range directions {
+			nx, ny := current.x+dir.dx, current.y+dir.dy
+			if nx >= 0 && nx < l.Size.Width && ny >= 0 && ny < l.Size.Height {
+				if !l.Cells[ny][nx].IsWall && !l.Cells[ny][nx].Visited {
+					l.Cells[ny][nx].Visited = true
+					queue = append(queue, struct{ x, y int }{nx, ny})
+				}
+			}
+		}
+	}
+
+	// Если финиш не достижим, создаем путь
+	if !l.Cells[l.Finish.Y][l.Finish.X].Visited {
+		l.createDirectPathToFinish()
+	}
+}
+
+// createDirectPathToFinish создает прямой путь к финишу
+func (l *Level) createDirectPathToFinish() {
+	// Создаем L-образный путь от старта к финишу
+	// Сначала двигаемся по горизонтали, затем по вертикали
+	for x := 0; x < l.Size.Width; x++ {
+		l.Cells[0][x].IsWall = false
+	}
+	for y := 0; y < l.Size.Height; y++ {
+		l.Cells[y][l.Size.Width-1].IsWall = false
+	}
+}
+
+// connectIsolatedAreas соединяет изолированные области
+func (l *Level) connectIsolatedAreas(rng *rand.Rand) {
+	// Делаем дополнительные проходы в случайных местах
+	for i := 0; i < l.Size.Width*l.Size.Height/10; i++ {
+		x := rng.Intn(l.Size.Width-2) + 1
+		y := rng.Intn(l.Size.Height-2) + 1
+
+		// Делаем крестообразный проход
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				if dx == 0 || dy == 0 { // Только вертикальные и горизонтальные
+					nx, ny := x+dx, y+dy
+					if nx >= 0 && nx < l.Size.Width && ny >= 0 && ny < l.Size.Height {
+						if rng.Float64() < 0.5 {
+							l.Cells[ny][nx].IsWall = false
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// createOpenSpace2x2 создает как минимум одну открытую область 2x2
+func (l *Level) createOpenSpace2x2(rng *rand.Rand) {
+	// Выбираем случайную позицию для открытой области
+	// Оставляем место для стен по краям
+	x := rng.Intn(l.Size.Width-4) + 2
+	y := rng.Intn(l.Size.Height-4) + 2
+
+	// Создаем область 2x2 без стен
+	for dy := 0; dy < 2; dy++ {
+		for dx := 0; dx < 2; dx++ {
+			if y+dy < l.Size.Height && x+dx < l.Size.Width {
+				l.Cells[y+dy][x+dx].IsWall = false
+			}
+		}
+	}
+
+	// Обеспечиваем доступ к этой области, убирая стены вокруг нее
+	for dy := -1; dy <= 2; dy++ {
+		for dx := -1; dx <= 2; dx++ {
+			nx, ny := x+dx, y+dy
+			if nx >= 0 && nx < l.Size.Width && ny >= 0 && ny < l.Size.Height {
+				// Убираем стены по периметру области 2x2
+				if (dx == -1 || dx == 2 || dy == -1 || dy == 2) && rng.Float64() < 0.7 {
+					l.Cells[ny][nx].IsWall = false
+				}
+			}
+		}
+	}
+}
+
+// generateSolvable генерирует лабиринт, пока решатель не докажет, что уровень проходим
+func (l *Level) generateSolvable(rng *rand.Rand) {
+	result := SolveResult{}
+	for attempt := 0; attempt < MaxGenerationAttempts && !result.Solvable; attempt++ {
+		l.buildMaze(rng)
+		result = l.Solve()
+
+		// Финиш достижим, но не с нужным числом сверху: выбираем другое число
+		if !result.Solvable {
+			result = l.pickReachableFinish(rng)
+		}
+	}
+
+	// Запасной вариант: открываем поле
+	if !result.Solvable {
+		result = l.openField(rng)
+	}
+	l.OptimalMoves = len(result.Moves)
+	l.Difficulty = l.RateDifficulty()
+}
+
+// buildMaze генерирует лабиринт и проверяет его связность
+func (l *Level) buildMaze(rng *rand.Rand) {
+	// Генерируем лабиринт
+	l.GenerateMaze(rng)
+
+	// Убедимся, что лабиринт связан
+	l.EnsureConnectivity()
+
+	// Убедимся, что старт и финиш проходимы
+	l.Cells[l.Start.Y][l.Start.X].IsWall = false
+	l.Cells[l.Finish.Y][l.Finish.X].IsWall = false
+
+	l.placeTeleports(rng)
+}
+
+// placeTeleports ставит l.Size.Teleports пар телепортов на случайные клетки пола,
+// вид каждой пары выбирается случайно. Проходимость проверяет решатель после расстановки
+func (l *Level) placeTeleports(rng *rand.Rand) {
+	if l.Size.Teleports == 0 {
+		return
+	}
+
+	var free []Cell
+	for _, row := range l.Cells {
+		for _, cell := range row {
+			isStart := cell.X == l.Start.X && cell.Y == l.Start.Y
+			isFinish := cell.X == l.Finish.X && cell.Y == l.Finish.Y
+			if !cell.IsWall && !cell.IsSpecial() && !isStart && !isFinish {
+				free = append(free, cell)
+			}
+		}
+	}
+	rng.Shuffle(len(free), func(i, j int) {
+		free[i], free[j] = free[j], free[i]
+	})
+
+	for i := 0; i < l.Size.Teleports && 2*i+1 < len(free); i++ {
+		a, b := free[2*i], free[2*i+1]
+		kind := CellTeleport
+		if rng.Intn(2) == 1 {
+			kind = CellTeleportReset
+		}
+		l.Cells[a.Y][a.X] = Cell{X: a.X, Y: a.Y, Kind: kind, PairX: b.X, PairY: b.Y}
+		l.Cells[b.Y][b.X] = Cell{X: b.X, Y: b.Y, Kind: kind, PairX: a.X, PairY: a.Y}
+	}
+}
+
+// pickReachableFinish ставит на финиш случайное из чисел, которые могут
+// оказаться на нем сверху кубика, и решает уровень заново
+func (l *Level) pickReachableFinish(rng *rand.Rand) SolveResult {
+	if tops := l.ReachableTops(l.Finish.X, l.Finish.Y); len(tops) > 0 {
+		l.Finish.Number = tops[rng.Intn(len(tops))]
+	}
+	return l.Solve()
+}
+
+// openField убирает внутренние стены, если проходимый лабиринт так и не
+// получился. Финиш остается достижимым, а на открытом поле кубик приходит
+// на него с разными числами сверху, поэтому число на финише выбирается заново
+func (l *Level) openField(rng *rand.Rand) SolveResult {
+	l.clearInnerWalls()
+	return l.pickReachableFinish(rng)
+}
+
+// clearInnerWalls убирает все внутренние стены лабиринта
+func (l *Level) clearInnerWalls() {
+	for y := 1; y < l.Size.Height-1; y++ {
+		for x := 1; x < l.Size.Width-1; x++ {
+			l.Cells[y][x].IsWall = false
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PackMetaFile имя файла с описанием набора уровней
+const PackMetaFile = "pack.json"
+
+// PackLevelExt расширение файлов уровней в наборе
+const PackLevelExt = ".txt"
+
+// Pack набор уровней: каталог с pack.json и файлами уровней в текстовом формате
+//
+//	{"name": "Tutorial", "author": "...", "levels": ["01-first.txt", "02-turns.txt"]}
+//
+// Если список levels не задан, уровни идут в порядке имен файлов *.txt
+type Pack struct {
+	ID     string   `json:"-"` // имя каталога, по нему хранится прогресс
+	Dir    string   `json:"-"`
+	Name   string   `json:"name"`
+	Author string   `json:"author"`
+	Levels []string `json:"levels"`
+}
+
+// LoadPack загружает описание набора уровней из каталога
+func LoadPack(dir string) (*Pack, error) {
+	data, err := os.ReadFile(filepath.Join(dir, PackMetaFile))
+	if err != nil {
+		return nil, err
+	}
+	p := &Pack{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Join(dir, PackMetaFile), err)
+	}
+	p.ID = filepath.Base(dir)
+	p.Dir = dir
+	if p.Name == "" {
+		p.Name = p.ID
+	}
+
+	if len(p.Levels) == 0 {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), PackLevelExt) {
+				p.Levels = append(p.Levels, entry.Name())
+			}
+		}
+		sort.Strings(p.Levels)
+	}
+	if len(p.Levels) == 0 {
+		return nil, fmt.Errorf("%s: в наборе нет уровней", dir)
+	}
+	// Уровни лежат в самом каталоге набора: пути из pack.json не должны вести за его пределы
+	for _, name := range p.Levels {
+		if name == "" || name == "." || name == ".." || filepath.Base(name) != name {
+			return nil, fmt.Errorf("%s: имя уровня %q должно быть именем файла в каталоге набора", dir, name)
+		}
+	}
+	return p, nil
+}
+
+// LoadPacks загружает все наборы уровней из подкаталогов root, упорядочивая их по имени каталога
+func LoadPacks(root string) ([]*Pack, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	var packs []*Pack
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, entry.Name())
+		if _, err := os.Stat(filepath.Join(dir, PackMetaFile)); err != nil {
+			continue
+		}
+		p, err := LoadPack(dir)
+		if err != nil {
+			return nil, err
+		}
+		packs = append(packs, p)
+	}
+	return packs, nil
+}
+
+// LoadLevel загружает уровень набора по номеру (с нуля)
+func (p *Pack) LoadLevel(index int) (Level, error) {
+	if index < 0 || index >= len(p.Levels) {
+		return Level{}, fmt.Errorf("в наборе %q нет уровня %d", p.Name, index+1)
+	}
+	return LoadLevel(filepath.Join(p.Dir, p.Levels[index]))
+}
+
+// Progress прогресс игрока по наборам уровней
+type Progress struct {
+	Completed map[string]int `json:"completed"` // число пройденных подряд уровней по ID набора
+}
+
+// DefaultProgressPath возвращает путь к файлу прогресса в каталоге настроек пользователя
+func DefaultProgressPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "kubegame", "progress.json"), nil
+}
+
+// LoadProgress загружает прогресс; отсутствующий файл означает пустой прогресс
+func LoadProgress(path string) (*Progress, error) {
+	p := &Progress{Completed: make(map[string]int)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return p, err
+	}
+	if err := json.Unmarshal(data, p); err != nil {
+		return p, fmt.Errorf("%s: %w", path, err)
+	}
+	if p.Completed == nil {
+		p.Completed = make(map[string]int)
+	}
+	return p, nil
+}
+
+// SaveProgress сохраняет прогресс
+func SaveProgress(path string, p *Progress) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Unlocked возвращает число открытых уровней набора: пройденные и следующий за ними
+func (p *Progress) Unlocked(pack *Pack) int {
+	return min(p.Completed[pack.ID]+1, len(pack.Levels))
+}
+
+// Complete отмечает уровень набора пройденным и открывает следующий;
+// возвращает true, если прогресс изменился
+func (p *Progress) Complete(pack *Pack, index int) bool {
+	if index+1 <= p.Completed[pack.ID] {
+		return false
+	}
+	p.Completed[pack.ID] = index + 1
+	return true
+}
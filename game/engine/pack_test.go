@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTestPack создает каталог набора с pack.json и файлами уровней
+func writeTestPack(t *testing.T, root, id, meta string, levels ...string) string {
+	t.Helper()
+	dir := filepath.Join(root, id)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{PackMetaFile: meta}
+	for _, name := range levels {
+		files[name] = testLevels["plain"]
+	}
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadPack(t *testing.T) {
+	root := t.TempDir()
+	tests := []struct {
+		name   string
+		meta   string
+		files  []string
+		want   []string
+		title  string
+		errMsg string
+	}{
+		{"listed levels", `{"name": "Listed", "levels": ["b.txt", "a.txt"]}`, []string{"a.txt", "b.txt"}, []string{"b.txt", "a.txt"}, "Listed", ""},
+		{"levels by file name", `{}`, []string{"02.txt", "01.txt", "notes.md"}, []string{"01.txt", "02.txt"}, "levels by file name", ""},
+		{"no levels", `{"name": "Empty"}`, nil, nil, "", "нет уровней"},
+		{"broken meta", `{"levels": 1}`, nil, nil, "", PackMetaFile},
+		{"level outside pack", `{"levels": ["../secret.txt"]}`, nil, nil, "", "имя уровня"},
+		{"level in subdirectory", `{"levels": ["sub/a.txt"]}`, nil, nil, "", "имя уровня"},
+		{"parent directory", `{"levels": [".."]}`, nil, nil, "", "имя уровня"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeTestPack(t, root, tt.name, tt.meta, tt.files...)
+			p, err := LoadPack(dir)
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Errorf("LoadPack error %v, want containing %q", err, tt.errMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadPack: %v", err)
+			}
+			if p.ID != tt.name || p.Name != tt.title || !reflect.DeepEqual(p.Levels, tt.want) {
+				t.Errorf("pack %q %q levels %v, want %q %q %v", p.ID, p.Name, p.Levels, tt.name, tt.title, tt.want)
+			}
+			if _, err := p.LoadLevel(0); err != nil {
+				t.Errorf("LoadLevel(0): %v", err)
+			}
+			if _, err := p.LoadLevel(len(p.Levels)); err == nil {
+				t.Error("LoadLevel past the last level succeeded")
+			}
+		})
+	}
+}
+
+func TestProgress(t *testing.T) {
+	pack := &Pack{ID: "tutorial", Levels: []string{"1.txt", "2.txt", "3.txt"}}
+	p := &Progress{Completed: map[string]int{}}
+
+	steps := []struct {
+		name     string
+		complete int // номер пройденного уровня, -1 — ничего не пройдено
+		changed  bool
+		unlocked int
+	}{
+		{"new pack", -1, false, 1},
+		{"first level", 0, true, 2},
+		{"first level again", 0, false, 2},
+		{"last level", 2, true, 3},
+		{"middle level after last", 1, false, 3},
+	}
+	for _, s := range steps {
+		if s.complete >= 0 {
+			if changed := p.Complete(pack, s.complete); changed != s.changed {
+				t.Errorf("%s: Complete = %v, want %v", s.name, changed, s.changed)
+			}
+		}
+		if got := p.Unlocked(pack); got != s.unlocked {
+			t.Errorf("%s: Unlocked = %d, want %d", s.name, got, s.unlocked)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "progress.json")
+	if err := SaveProgress(path, p); err != nil {
+		t.Fatalf("SaveProgress: %v", err)
+	}
+	loaded, err := LoadProgress(path)
+	if err != nil || !reflect.DeepEqual(loaded, p) {
+		t.Errorf("LoadProgress = %+v, %v; want %+v", loaded, err, p)
+	}
+	if empty, err := LoadProgress(filepath.Join(t.TempDir(), "missing.json")); err != nil || len(empty.Completed) != 0 {
+		t.Errorf("LoadProgress of a missing file = %+v, %v", empty, err)
+	}
+}
@@ -0,0 +1,239 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ReplayVersion версия файла записи игры
+const ReplayVersion = 1
+
+// ReplayAction действие игрока в записи, хранится одним символом
+type ReplayAction byte
+
+const (
+	ActionUp      ReplayAction = 'U'
+	ActionDown    ReplayAction = 'D'
+	ActionLeft    ReplayAction = 'L'
+	ActionRight   ReplayAction = 'R'
+	ActionUndo    ReplayAction = 'z'
+	ActionRedo    ReplayAction = 'x'
+	ActionRestart ReplayAction = 'r'
+)
+
+// MoveAction возвращает действие хода в направлении dir
+func MoveAction(dir Direction) ReplayAction {
+	return [...]ReplayAction{Up: ActionUp, Down: ActionDown, Left: ActionLeft, Right: ActionRight}[dir]
+}
+
+// Apply выполняет действие на уровне; возвращает false, если оно ничего не изменило
+func (a ReplayAction) Apply(l *Level) bool {
+	switch a {
+	case ActionUp:
+		return l.TryMove(Up)
+	case ActionDown:
+		return l.TryMove(Down)
+	case ActionLeft:
+		return l.TryMove(Left)
+	case ActionRight:
+		return l.TryMove(Right)
+	case ActionUndo:
+		return l.Undo()
+	case ActionRedo:
+		return l.Redo()
+	case ActionRestart:
+		l.Restart()
+		return true
+	}
+	return false
+}
+
+// Replay запись игры: уровень в начальном состоянии и принятые действия игрока
+type Replay struct {
+	Version int    `json:"version"`
+	Level   Level  `json:"level"`
+	Actions string `json:"actions"` // символы ReplayAction по порядку
+}
+
+// NewReplay начинает запись игры на уровне
+func NewReplay(l *Level) *Replay {
+	r := &Replay{}
+	r.Reset(l)
+	return r
+}
+
+// Reset начинает запись заново на другом уровне. Если на уровне уже есть
+// история ходов (например, после продолжения сохраненной игры), она
+// записывается так, чтобы запись приводила к текущему состоянию
+func (r *Replay) Reset(l *Level) {
+	start := *l
+	start.Player = start.StartPlayer()
+	start.Won = false
+	start.History, start.Future = nil, nil
+	*r = Replay{Version: ReplayVersion, Level: start}
+
+	for _, record := range l.History {
+		r.Record(MoveAction(record.Dir))
+	}
+	// Отмененные ходы: проходим их и отменяем, чтобы их можно было повторить
+	for i := len(l.Future) - 1; i >= 0; i-- {
+		r.Record(MoveAction(l.Future[i].Dir))
+	}
+	for range l.Future {
+		r.Record(ActionUndo)
+	}
+}
+
+// Record добавляет принятое действие в запись
+func (r *Replay) Record(action ReplayAction) {
+	r.Actions += string(action)
+}
+
+// Len возвращает число записанных действий
+func (r *Replay) Len() int {
+	return len(r.Actions)
+}
+
+// StateAt возвращает уровень после первых n действий записи
+func (r *Replay) StateAt(n int) Level {
+	l := r.Level
+	l.History, l.Future = nil, nil
+	for i := 0; i < n && i < len(r.Actions); i++ {
+		ReplayAction(r.Actions[i]).Apply(&l)
+	}
+	return l
+}
+
+// DefaultReplayDir возвращает каталог записей в каталоге настроек пользователя
+func DefaultReplayDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "kubegame", "replays"), nil
+}
+
+// SaveReplay сохраняет запись игры в файл
+func SaveReplay(path string, r *Replay) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// LoadReplay загружает запись игры и проверяет, что все действия в ней допустимы
+func LoadReplay(path string) (*Replay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &Replay{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if r.Version < 1 || r.Version > ReplayVersion {
+		return nil, fmt.Errorf("%s: неподдерживаемая версия записи %d", path, r.Version)
+	}
+
+	l := r.Level
+	for i := 0; i < len(r.Actions); i++ {
+		if !ReplayAction(r.Actions[i]).Apply(&l) {
+			return nil, fmt.Errorf("%s: действие %d (%q) невозможно", path, i+1, r.Actions[i])
+		}
+	}
+	return r, nil
+}
+
+// Скорость воспроизведения в действиях в секунду
+const (
+	DefaultReplaySpeed = 2.0
+	MinReplaySpeed     = 0.5
+	MaxReplaySpeed     = 16.0
+)
+
+// ReplayPlayer воспроизводит запись с паузой, шагами и выбором скорости
+type ReplayPlayer struct {
+	Replay  *Replay
+	Level   Level   // состояние после первых Pos действий
+	Pos     int     // число воспроизведенных действий
+	Playing bool    // идет ли автоматическое воспроизведение
+	Speed   float64 // действий в секунду
+	elapsed float64
+}
+
+// NewReplayPlayer создает проигрыватель записи, стоящий на паузе в начале
+func NewReplayPlayer(r *Replay) *ReplayPlayer {
+	return &ReplayPlayer{Replay: r, Level: r.StateAt(0), Speed: DefaultReplaySpeed}
+}
+
+// TogglePlay запускает или ставит на паузу воспроизведение
+func (p *ReplayPlayer) TogglePlay() {
+	if !p.Playing && p.Done() {
+		p.Seek(0) // запуск после конца начинает запись сначала
+	}
+	p.Playing = !p.Playing
+	p.elapsed = 0
+}
+
+// Done сообщает, воспроизведены ли все действия
+func (p *ReplayPlayer) Done() bool {
+	return p.Pos >= p.Replay.Len()
+}
+
+// Step воспроизводит следующее действие
+func (p *ReplayPlayer) Step() bool {
+	if p.Done() {
+		return false
+	}
+	ReplayAction(p.Replay.Actions[p.Pos]).Apply(&p.Level)
+	p.Pos++
+	return true
+}
+
+// StepBack возвращается на одно действие назад
+func (p *ReplayPlayer) StepBack() bool {
+	if p.Pos == 0 {
+		return false
+	}
+	p.Seek(p.Pos - 1)
+	return true
+}
+
+// Seek переходит к состоянию после первых n действий
+func (p *ReplayPlayer) Seek(n int) {
+	n = max(0, min(n, p.Replay.Len()))
+	p.Level = p.Replay.StateAt(n)
+	p.Pos = n
+}
+
+// Faster удваивает скорость воспроизведения
+func (p *ReplayPlayer) Faster() {
+	p.Speed = min(p.Speed*2, MaxReplaySpeed)
+}
+
+// Slower уменьшает скорость воспроизведения вдвое
+func (p *ReplayPlayer) Slower() {
+	p.Speed = max(p.Speed/2, MinReplaySpeed)
+}
+
+// Update продвигает воспроизведение на dt секунд
+func (p *ReplayPlayer) Update(dt float64) {
+	if !p.Playing {
+		return
+	}
+	p.elapsed += dt
+	for p.elapsed >= 1/p.Speed {
+		p.elapsed -= 1 / p.Speed
+		if !p.Step() {
+			p.Playing = false
+			p.elapsed = 0
+			return
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// recordActions выполняет действия на уровне и записывает принятые,
+// возвращая состояние игрока после каждого из них
+func recordActions(t *testing.T, l *Level, r *Replay, actions string) []Player {
+	t.Helper()
+	states := []Player{l.Player}
+	for i := 0; i < len(actions); i++ {
+		action := ReplayAction(actions[i])
+		if !action.Apply(l) {
+			t.Fatalf("action %q is not possible", action)
+		}
+		r.Record(action)
+		states = append(states, l.Player)
+	}
+	return states
+}
+
+func TestReplayStateAt(t *testing.T) {
+	l := parseTestLevel(t, "finish 1\nS..\n..F\n")
+	r := NewReplay(&l)
+	states := recordActions(t, &l, r, "RDzxrRR")
+
+	for n, want := range states {
+		if got := r.StateAt(n); got.Player != want {
+			t.Errorf("StateAt(%d) player %+v, want %+v", n, got.Player, want)
+		}
+	}
+	if got := r.StateAt(len(states) + 5); got.Player != l.Player {
+		t.Errorf("StateAt past the end player %+v, want %+v", got.Player, l.Player)
+	}
+}
+
+func TestReplayResetKeepsHistory(t *testing.T) {
+	l := parseTestLevel(t, "finish 1\nS..\n..F\n")
+	l.TryMove(Right)
+	l.TryMove(Down)
+	l.TryMove(Right)
+	l.Undo()
+
+	// Запись продолженной игры приводит к тому же состоянию, и отмененный ход можно повторить
+	r := NewReplay(&l)
+	got := r.StateAt(r.Len())
+	if got.Player != l.Player || len(got.History) != len(l.History) || len(got.Future) != len(l.Future) {
+		t.Errorf("replayed player %+v, %d moves, %d undone; want %+v, %d, %d",
+			got.Player, len(got.History), len(got.Future), l.Player, len(l.History), len(l.Future))
+	}
+	if start := r.StateAt(0); start.Player != l.StartPlayer() {
+		t.Errorf("replay starts at %+v, want %+v", start.Player, l.StartPlayer())
+	}
+}
+
+func TestLoadReplay(t *testing.T) {
+	l := parseTestLevel(t, "finish 1\nS..\n..F\n")
+	r := NewReplay(&l)
+	recordActions(t, &l, r, "RDzx")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "replay.json")
+	if err := SaveReplay(path, r); err != nil {
+		t.Fatalf("SaveReplay: %v", err)
+	}
+	loaded, err := LoadReplay(path)
+	if err != nil {
+		t.Fatalf("LoadReplay: %v", err)
+	}
+	if loaded.Actions != r.Actions || loaded.StateAt(loaded.Len()).Player != l.Player {
+		t.Errorf("loaded actions %q, want %q", loaded.Actions, r.Actions)
+	}
+
+	// Запись с невозможным действием отвергается
+	r.Record(ActionUp)
+	r.Record(ActionUp)
+	if err := SaveReplay(path, r); err != nil {
+		t.Fatalf("SaveReplay: %v", err)
+	}
+	if _, err := LoadReplay(path); err == nil {
+		t.Error("LoadReplay accepted an impossible action")
+	}
+}
+
+func TestReplayPlayer(t *testing.T) {
+	l := parseTestLevel(t, "finish 1\nS..\n..F\n")
+	r := NewReplay(&l)
+	states := recordActions(t, &l, r, "RDR")
+
+	p := NewReplayPlayer(r)
+	p.TogglePlay()
+	p.Update(1 / DefaultReplaySpeed)
+	if p.Pos != 1 || p.Level.Player != states[1] {
+		t.Errorf("after one tick at %d: player %+v, want %+v", p.Pos, p.Level.Player, states[1])
+	}
+	p.Update(10)
+	if !p.Done() || p.Playing || p.Level.Player != states[3] {
+		t.Errorf("after playing to the end: pos %d, playing %v, player %+v", p.Pos, p.Playing, p.Level.Player)
+	}
+	if !p.StepBack() || p.Level.Player != states[2] {
+		t.Errorf("after StepBack player %+v, want %+v", p.Level.Player, states[2])
+	}
+	p.Seek(0)
+	if p.StepBack() || p.Level.Player != states[0] {
+		t.Errorf("StepBack at the start moved to %+v", p.Level.Player)
+	}
+}
@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SaveVersion версия файла сохранения
+const SaveVersion = 1
+
+// saveFile сохранение незаконченной игры: уровень с игроком и история ходов
+type saveFile struct {
+	Version int         `json:"version"`
+	Level   Level       `json:"level"`
+	History []Direction `json:"history"`        // ходы от старта до текущей позиции
+	Future  []Direction `json:"future"`         // отмененные ходы, последний повторяется первым
+	Pack    *SavedPack  `json:"pack,omitempty"` // набор, из которого взят уровень
+}
+
+// SavedPack уровень набора, на котором сохранена игра; по нему после
+// продолжения игры засчитывается прогресс
+type SavedPack struct {
+	ID    string `json:"id"`    // Pack.ID
+	Index int    `json:"index"` // номер уровня в наборе
+}
+
+// DefaultSavePath возвращает путь к файлу сохранения в каталоге настроек пользователя
+func DefaultSavePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "kubegame", "save.json"), nil
+}
+
+// SaveGame сохраняет уровень, позицию игрока и историю ходов;
+// pack равен nil для уровня не из набора
+func SaveGame(path string, l *Level, pack *SavedPack) error {
+	save := saveFile{Version: SaveVersion, Level: *l, Pack: pack}
+	for _, record := range l.History {
+		save.History = append(save.History, record.Dir)
+	}
+	for _, record := range l.Future {
+		save.Future = append(save.Future, record.Dir)
+	}
+
+	data, err := json.MarshalIndent(save, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	// Пишем во временный файл, чтобы не испортить старое сохранение при сбое
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadGame загружает сохраненную игру и восстанавливает историю ходов,
+// проигрывая ее от старта уровня. Для уровня не из набора pack равен nil
+func LoadGame(path string) (Level, *SavedPack, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Level{}, nil, err
+	}
+	var save saveFile
+	if err := json.Unmarshal(data, &save); err != nil {
+		return Level{}, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if save.Version < 1 || save.Version > SaveVersion {
+		return Level{}, nil, fmt.Errorf("%s: неподдерживаемая версия сохранения %d", path, save.Version)
+	}
+
+	l := save.Level
+	saved := l.Player
+	l.Player = l.StartPlayer()
+	for _, dir := range save.History {
+		if !l.TryMove(dir) {
+			return Level{}, nil, fmt.Errorf("%s: ход %s из истории невозможен", path, dir)
+		}
+	}
+	if l.Player != saved {
+		return Level{}, nil, fmt.Errorf("%s: история ходов не приводит к сохраненной позиции", path)
+	}
+
+	// Стек отмененных ходов: последний в списке повторяется первым
+	l.Future = make([]MoveRecord, len(save.Future))
+	current := l.Player
+	for i := len(save.Future) - 1; i >= 0; i-- {
+		dir := save.Future[i]
+		l.Future[i] = MoveRecord{Dir: dir, Before: current}
+		next, ok := l.NextState(current, dir)
+		if !ok {
+			return Level{}, nil, fmt.Errorf("%s: отмененный ход %s невозможен", path, dir)
+		}
+		current = next
+	}
+	return l, save.Pack, nil
+}
+
+// RemoveSave удаляет файл сохранения, если он есть
+func RemoveSave(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveGameRoundTrip(t *testing.T) {
+	want := parseTestLevel(t, testLevels["die and seed"])
+	want.TryMove(Right)
+	want.TryMove(Up)
+	want.Undo()
+
+	path := filepath.Join(t.TempDir(), "save.json")
+	if err := SaveGame(path, &want, &SavedPack{ID: "tutorial", Index: 2}); err != nil {
+		t.Fatalf("SaveGame: %v", err)
+	}
+	got, pack, err := LoadGame(path)
+	if err != nil {
+		t.Fatalf("LoadGame: %v", err)
+	}
+	sameLevel(t, &got, &want)
+	if pack == nil || *pack != (SavedPack{ID: "tutorial", Index: 2}) {
+		t.Errorf("pack %+v, want tutorial level 2", pack)
+	}
+	if got.Player != want.Player || len(got.History) != len(want.History) || len(got.Future) != len(want.Future) {
+		t.Errorf("player %+v, %d moves, %d undone; want %+v, %d, %d",
+			got.Player, len(got.History), len(got.Future), want.Player, len(want.History), len(want.Future))
+	}
+
+	// Отмененный ход восстанавливается и повторяется
+	want.Redo()
+	if !got.Redo() || got.Player != want.Player {
+		t.Errorf("after Redo player %+v, want %+v", got.Player, want.Player)
+	}
+}
+
+func TestSaveGameWithoutPack(t *testing.T) {
+	l := parseTestLevel(t, testLevels["plain"])
+	path := filepath.Join(t.TempDir(), "save.json")
+	if err := SaveGame(path, &l, nil); err != nil {
+		t.Fatalf("SaveGame: %v", err)
+	}
+	if _, pack, err := LoadGame(path); err != nil || pack != nil {
+		t.Errorf("LoadGame = pack %+v, %v; want no pack", pack, err)
+	}
+}
+
+func TestLoadGameRejectsBrokenHistory(t *testing.T) {
+	l := parseTestLevel(t, testLevels["plain"])
+	l.TryMove(Right)
+
+	path := filepath.Join(t.TempDir(), "save.json")
+	if err := SaveGame(path, &l, nil); err != nil {
+		t.Fatalf("SaveGame: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Ход в стену вместо записанного
+	broken := strings.Replace(string(data), `"Right"`, `"Up"`, 1)
+	if err := os.WriteFile(path, []byte(broken), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := LoadGame(path); err == nil {
+		t.Error("LoadGame accepted a history that does not replay")
+	}
+}
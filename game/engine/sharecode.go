@@ -0,0 +1,158 @@
+package engine
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// Код уровня для обмена в чате: префикс ShareCodePrefix и base64url от байтов
+//
+//	версия, ширина, высота, старт x y, финиш x y,
+//	число финиша (3 бита) и ориентация стартового кубика (5 бит),
+//	стены построчно по одному биту на клетку,
+//	с версии 2 особые клетки по shareTileSize байт: x, y, вид, два параметра
+//	(число замка и запрещенные числа или координаты пары телепорта)
+//
+// Уровень 15x10 без особых клеток занимает 27 байт, то есть около 40 символов.
+// Такие уровни записываются версией 1, чтобы коды оставались короткими.
+
+// ShareCodePrefix префикс кода уровня
+const ShareCodePrefix = "KG"
+
+// ShareCodeVersion версия формата кода уровня
+const ShareCodeVersion = 2
+
+// shareCodeHeader число байтов заголовка кода до стен
+const shareCodeHeader = 8
+
+// shareTileSize число байтов на одну особую клетку
+const shareTileSize = 5
+
+// EncodeShareCode упаковывает уровень в короткий код
+func EncodeShareCode(l *Level) (string, error) {
+	w, h := l.Size.Width, l.Size.Height
+	if w < 1 || w > 255 || h < 1 || h > 255 {
+		return "", fmt.Errorf("уровень %dx%d слишком велик для кода", w, h)
+	}
+	orientation := l.Start.Die.Orientation()
+	if orientation < 0 {
+		return "", fmt.Errorf("кубик на старте нельзя получить перекатыванием")
+	}
+
+	tiles := l.Tiles()
+	data := make([]byte, shareCodeHeader+(w*h+7)/8)
+	data[0] = 1
+	if len(tiles) > 0 {
+		data[0] = ShareCodeVersion
+	}
+	data[1], data[2] = byte(w), byte(h)
+	data[3], data[4] = byte(l.Start.X), byte(l.Start.Y)
+	data[5], data[6] = byte(l.Finish.X), byte(l.Finish.Y)
+	data[7] = byte(l.Finish.Number)<<5 | byte(orientation)
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if l.Cells[y][x].IsWall {
+				bit := y*w + x
+				data[shareCodeHeader+bit/8] |= 1 << (bit % 8)
+			}
+		}
+	}
+
+	for _, tile := range tiles {
+		data = append(data, encodeTile(tile)...)
+	}
+
+	return ShareCodePrefix + base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeShareCode восстанавливает уровень из кода
+func DecodeShareCode(code string) (Level, error) {
+	code = strings.TrimSpace(code)
+	if !strings.HasPrefix(code, ShareCodePrefix) {
+		return Level{}, fmt.Errorf("код уровня должен начинаться с %q", ShareCodePrefix)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(code[len(ShareCodePrefix):])
+	if err != nil {
+		return Level{}, fmt.Errorf("код уровня поврежден: %w", err)
+	}
+	if len(data) < shareCodeHeader {
+		return Level{}, fmt.Errorf("код уровня слишком короткий")
+	}
+	version := data[0]
+	if version < 1 || version > ShareCodeVersion {
+		return Level{}, fmt.Errorf("неподдерживаемая версия кода %d", version)
+	}
+
+	w, h := int(data[1]), int(data[2])
+	wallsEnd := shareCodeHeader + (w*h+7)/8
+	tilesSize := len(data) - wallsEnd
+	if w < 1 || h < 1 || tilesSize < 0 || (version == 1 && tilesSize != 0) || tilesSize%shareTileSize != 0 {
+		return Level{}, fmt.Errorf("длина кода не совпадает с размером %dx%d", w, h)
+	}
+	orientations := DieOrientations()
+	orientation := int(data[7] & 0x1f)
+	if orientation >= len(orientations) {
+		return Level{}, fmt.Errorf("неизвестная ориентация кубика %d", orientation)
+	}
+
+	l := Level{}
+	l.Size.Width, l.Size.Height = w, h
+	l.Start.X, l.Start.Y = int(data[3]), int(data[4])
+	l.Start.Die = orientations[orientation]
+	l.Finish.X, l.Finish.Y = int(data[5]), int(data[6])
+	l.Finish.Number = int(data[7] >> 5)
+	switch {
+	case !l.inBounds(l.Start.X, l.Start.Y):
+		return Level{}, fmt.Errorf("старт (%d,%d) вне уровня", l.Start.X, l.Start.Y)
+	case !l.inBounds(l.Finish.X, l.Finish.Y):
+		return Level{}, fmt.Errorf("финиш (%d,%d) вне уровня", l.Finish.X, l.Finish.Y)
+	case l.Finish.Number < 1 || l.Finish.Number > 6:
+		return Level{}, fmt.Errorf("число финиша должно быть от 1 до 6, получено %d", l.Finish.Number)
+	}
+
+	l.Cells = make([][]Cell, h)
+	for y := 0; y < h; y++ {
+		l.Cells[y] = make([]Cell, w)
+		for x := 0; x < w; x++ {
+			bit := y*w + x
+			wall := data[shareCodeHeader+bit/8]&(1<<(bit%8)) != 0
+			l.Cells[y][x] = Cell{X: x, Y: y, IsWall: wall}
+		}
+	}
+	if err := l.checkEndpoints(); err != nil {
+		return Level{}, err
+	}
+
+	for i := wallsEnd; i < len(data); i += shareTileSize {
+		if err := l.setTile(decodeTile(data[i : i+shareTileSize])); err != nil {
+			return Level{}, err
+		}
+	}
+	if err := l.checkTeleports(); err != nil {
+		return Level{}, err
+	}
+
+	l.prepare()
+	return l, nil
+}
+
+// encodeTile упаковывает особую клетку в shareTileSize байт
+func encodeTile(tile Cell) []byte {
+	if tile.IsTeleport() {
+		return []byte{byte(tile.X), byte(tile.Y), byte(tile.Kind), byte(tile.PairX), byte(tile.PairY)}
+	}
+	return []byte{byte(tile.X), byte(tile.Y), byte(tile.Kind), byte(tile.Number), byte(tile.Forbidden)}
+}
+
+// decodeTile распаковывает особую клетку
+func decodeTile(data []byte) Cell {
+	tile := Cell{X: int(data[0]), Y: int(data[1]), Kind: CellKind(data[2])}
+	if tile.IsTeleport() {
+		tile.PairX, tile.PairY = int(data[3]), int(data[4])
+	} else {
+		tile.Number, tile.Forbidden = int(data[3]), FaceSet(data[4])
+	}
+	return tile
+}
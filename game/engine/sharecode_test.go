@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestShareCodeRoundTrip(t *testing.T) {
+	levels := map[string]Level{}
+	for name, text := range testLevels {
+		levels[name] = parseTestLevel(t, text)
+	}
+	for _, a := range []MazeAlgorithm{AlgorithmBacktracker, AlgorithmCaves} {
+		levels["generated "+a.String()] = NewLevel(LevelSize{Width: 20, Height: 12, Seed: 3, Algorithm: a, Teleports: 2})
+	}
+
+	for name, want := range levels {
+		t.Run(name, func(t *testing.T) {
+			code, err := EncodeShareCode(&want)
+			if err != nil {
+				t.Fatalf("EncodeShareCode: %v", err)
+			}
+			if !strings.HasPrefix(code, ShareCodePrefix) {
+				t.Errorf("code %q has no prefix %q", code, ShareCodePrefix)
+			}
+			got, err := DecodeShareCode(code)
+			if err != nil {
+				t.Fatalf("DecodeShareCode: %v", err)
+			}
+			sameLevel(t, &got, &want)
+		})
+	}
+}
+
+func TestDecodeShareCodeErrors(t *testing.T) {
+	tests := []string{"", "KG1-", ShareCodePrefix + "!!!", ShareCodePrefix + "AAAA"}
+	for _, code := range tests {
+		if _, err := DecodeShareCode(code); err == nil {
+			t.Errorf("DecodeShareCode(%q) accepted a broken code", code)
+		}
+	}
+}
+
+func TestDecodeShareCodeEndpoints(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(l *Level)
+	}{
+		{"wall on start", func(l *Level) { l.Cells[l.Start.Y][l.Start.X].IsWall = true }},
+		{"wall on finish", func(l *Level) { l.Cells[l.Finish.Y][l.Finish.X].IsWall = true }},
+		{"finish on start", func(l *Level) { l.Finish.X, l.Finish.Y = l.Start.X, l.Start.Y }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := parseTestLevel(t, testLevels["plain"])
+			tt.edit(&l)
+			code, err := EncodeShareCode(&l)
+			if err != nil {
+				t.Fatalf("EncodeShareCode: %v", err)
+			}
+			if _, err := DecodeShareCode(code); err == nil {
+				t.Errorf("DecodeShareCode(%q) accepted a level with a broken start or finish", code)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package engine
+
+import "strings"
+
+// SolveResult результат поиска решения уровня
+type SolveResult struct {
+	Moves    []Direction // кратчайшая последовательность ходов
+	Solvable bool        // существует ли решение
+	States   int         // количество исследованных состояний
+}
+
+// FormatMoves записывает ходы строкой из букв U, D, L, R
+func FormatMoves(moves []Direction) string {
+	var b strings.Builder
+	for _, dir := range moves {
+		b.WriteByte(byte(MoveAction(dir)))
+	}
+	return b.String()
+}
+
+// NextState возвращает состояние игрока после хода в направлении dir
+func (l *Level) NextState(p Player, dir Direction) (Player, bool) {
+	dx, dy := dir.Delta()
+	next := p
+	for step := 0; ; step++ {
+		// Со льда кубик соскальзывает, не перекатываясь
+		moved := next
+		moved.Move(dx, dy, dir, l.isIce(next.X, next.Y))
+		if !l.IsValidMove(moved.X, moved.Y, moved.Die) {
+			if step == 0 {
+				return p, false
+			}
+			// Скольжение уперлось в препятствие: кубик остается на льду
+			return next, true
+		}
+		next = l.Cells[moved.Y][moved.X].Enter(moved)
+
+		// На льду кубик продолжает движение в том же направлении
+		if !l.isIce(next.X, next.Y) {
+			return next, true
+		}
+	}
+}
+
+// Solve ищет кратчайшее решение от старта уровня
+func (l *Level) Solve() SolveResult {
+	return l.SolveFrom(l.StartPlayer())
+}
+
+// SolveFrom ищет кратчайшее решение из произвольного состояния игрока
+// Поиск в ширину идет по пространству (x, y, ориентация кубика),
+// у кубика 24 ориентации, достижимые через Die.Roll
+func (l *Level) SolveFrom(start Player) SolveResult {
+	type step struct {
+		prev int
+		dir  Direction
+		seen bool
+	}
+
+	steps := make([]step, l.stateCount())
+	startIndex := l.stateIndex(start)
+	steps[startIndex].seen = true
+	queue := []Player{start}
+	states := 1
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if l.IsWinState(current) {
+			// Восстанавливаем путь от финиша к старту
+			var moves []Direction
+			for i := l.stateIndex(current); i != startIndex; i = steps[i].prev {
+				moves = append(moves, steps[i].dir)
+			}
+			for i, j := 0, len(moves)-1; i < j; i, j = i+1, j-1 {
+				moves[i], moves[j] = moves[j], moves[i]
+			}
+			return SolveResult{Moves: moves, Solvable: true, States: states}
+		}
+
+		for _, dir := range Directions {
+			next, ok := l.NextState(current, dir)
+			if !ok {
+				continue
+			}
+			index := l.stateIndex(next)
+			if steps[index].seen {
+				continue
+			}
+			steps[index] = step{prev: l.stateIndex(current), dir: dir, seen: true}
+			states++
+			queue = append(queue, next)
+		}
+	}
+
+	return SolveResult{Solvable: false, States: states}
+}
+
+// stateCount возвращает число состояний (клетка, ориентация кубика) уровня
+func (l *Level) stateCount() int {
+	return l.Size.Width * l.Size.Height * 36
+}
+
+// stateIndex возвращает номер состояния игрока для поиска в ширину.
+// Ориентацию кубика однозначно задают числа сверху и спереди
+func (l *Level) stateIndex(p Player) int {
+	return ((p.Y*l.Size.Width+p.X)*6+p.Die.Top-1)*6 + p.Die.Front - 1
+}
+
+// ReachableTops возвращает числа, которые могут оказаться сверху
+// кубика в клетке (x, y) при игре от старта уровня
+func (l *Level) ReachableTops(x, y int) []int {
+	start := l.StartPlayer()
+	visited := make([]bool, l.stateCount())
+	visited[l.stateIndex(start)] = true
+	queue := []Player{start}
+	found := make(map[int]bool)
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if current.X == x && current.Y == y {
+			found[current.Die.Top] = true
+		}
+
+		for _, dir := range Directions {
+			next, ok := l.NextState(current, dir)
+			if ok && !visited[l.stateIndex(next)] {
+				visited[l.stateIndex(next)] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	var tops []int
+	for number := 1; number <= 6; number++ {
+		if found[number] {
+			tops = append(tops, number)
+		}
+	}
+	return tops
+}
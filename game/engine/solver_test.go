@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSolveFrom(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    string
+		from     *Player // nil — старт уровня
+		solvable bool
+		moves    []Direction
+	}{
+		{"corridor", "finish 6\nS.F\n", nil, true, []Direction{Right, Right}},
+		{"wrong parity", "finish 3\nS.F\n", nil, false, nil},
+		{"wall", "finish 1\nS#F\n", nil, false, nil},
+		{"detour", "finish 1\nS#F\n...\n", nil, true, []Direction{Down, Right, Right, Up}},
+		{"already won", "finish 1\nS.F\n", &Player{X: 2, Y: 0, Die: NewDie()}, true, nil},
+		{"through teleport", "finish 6\nteleport 1 0 1 2\nS.#\n###\n..F\n", nil, true, []Direction{Right, Right}},
+		{"over ice", "finish 3\nS~~F\n", nil, true, []Direction{Right}},
+		{"start in the middle", "finish 6\nF.S\n", nil, true, []Direction{Left, Left}},
+		{"lock passed", "finish 6\nS3F\n", nil, true, []Direction{Right, Right}},
+		{"lock blocks", "finish 1\nS4F\n", nil, false, nil},
+		{"lock under finish", "finish 6\ntile 2 0 lock 5\nS3F\n", nil, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := parseTestLevel(t, tt.level)
+			from := l.StartPlayer()
+			if tt.from != nil {
+				from = *tt.from
+			}
+			result := l.SolveFrom(from)
+			if result.Solvable != tt.solvable {
+				t.Fatalf("Solvable = %v, want %v", result.Solvable, tt.solvable)
+			}
+			if !reflect.DeepEqual(result.Moves, tt.moves) {
+				t.Errorf("moves %v, want %v", result.Moves, tt.moves)
+			}
+
+			// Решение действительно ведет к победе
+			current := from
+			for _, dir := range result.Moves {
+				var ok bool
+				if current, ok = l.NextState(current, dir); !ok {
+					t.Fatalf("move %d from %+v is invalid", dir, current)
+				}
+			}
+			if tt.solvable && !l.IsWinState(current) {
+				t.Errorf("solution ends at %+v", current)
+			}
+		})
+	}
+}
+
+func TestNextState(t *testing.T) {
+	rolled := NewDie()
+	rolled.Roll(Right)
+	rotated := rolled
+	rotated.Rotate(true)
+
+	tests := []struct {
+		name  string
+		level string
+		dir   Direction
+		ok    bool
+		want  Player
+	}{
+		{"floor", "finish 1\nS..F\n", Right, true, Player{1, 0, rolled}},
+		{"wall", "finish 1\nS#.F\n", Right, false, Player{0, 0, NewDie()}},
+		{"outside", "finish 1\nS..F\n", Up, false, Player{0, 0, NewDie()}},
+		{"lock accepts", "finish 1\nS3.F\n", Right, true, Player{1, 0, rolled}},
+		{"lock rejects", "finish 1\nS2.F\n", Right, false, Player{0, 0, NewDie()}},
+		{"forbid accepts", "finish 1\nSa.F\n", Right, true, Player{1, 0, rolled}},
+		{"forbid rejects", "finish 1\nSc.F\n", Right, false, Player{0, 0, NewDie()}},
+		{"rotator", "finish 1\nS).F\n", Right, true, Player{1, 0, rotated}},
+		{"teleport", "finish 1\nteleport 1 0 3 1\nS..F\n....\n", Right, true, Player{3, 1, rolled}},
+		{"ice slide", "finish 1\nS~~.F\n", Right, true, Player{3, 0, rolled}},
+		{"ice stops at wall", "finish 1\nS~~#F\n", Right, true, Player{2, 0, rolled}},
+		{"ice onto teleport", "finish 1\nteleport 3 0 0 1\nS~~..\n....F\n", Right, true, Player{0, 1, rolled}},
+		{"ice under start", "finish 1\ntile 0 0 ice\nS..F\n", Right, true, Player{1, 0, NewDie()}},
+		{"teleport reset", "finish 1\nteleport 1 0 3 1 reset\nS..F\n....\n", Right, true, Player{3, 1, NewDie()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := parseTestLevel(t, tt.level)
+			got, ok := l.NextState(l.StartPlayer(), tt.dir)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("NextState(%d) = %+v, %v, want %+v, %v", tt.dir, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestReachableTops(t *testing.T) {
+	tests := []struct {
+		name  string
+		level string
+		want  []int
+	}{
+		// В коридоре кубик катится вокруг одной оси, и число сверху зависит только от клетки
+		{"corridor", "finish 1\nS.F\n", []int{6}},
+		{"walled off", "finish 1\nS#F\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := parseTestLevel(t, tt.level)
+			if got := l.ReachableTops(l.Finish.X, l.Finish.Y); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReachableTops = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// CellKind вид клетки пола
+type CellKind int
+
+const (
+	CellFloor         CellKind = iota // обычный пол
+	CellLock                          // пропускает кубик, только если сверху Number
+	CellForbid                        // не пропускает кубик с числом из Forbidden сверху
+	CellRotateCW                      // поворачивает въехавший кубик по часовой стрелке
+	CellRotateCCW                     // поворачивает въехавший кубик против часовой стрелки
+	CellTeleport                      // переносит кубик на парную клетку, не меняя ориентацию
+	CellTeleportReset                 // переносит кубик на парную клетку в ориентации NewDie
+	CellIce                           // кубик скользит по льду, не перекатываясь
+	cellKindCount
+)
+
+// cellKindNames названия видов клеток для файлов уровней
+var cellKindNames = [...]string{
+	CellFloor:         "floor",
+	CellLock:          "lock",
+	CellForbid:        "forbid",
+	CellRotateCW:      "rotate-cw",
+	CellRotateCCW:     "rotate-ccw",
+	CellTeleport:      "teleport",
+	CellTeleportReset: "teleport-reset",
+	CellIce:           "ice",
+}
+
+// String возвращает название вида клетки
+func (k CellKind) String() string {
+	if k < 0 || k >= cellKindCount {
+		return "unknown"
+	}
+	return cellKindNames[k]
+}
+
+// MarshalText записывает вид клетки его названием
+func (k CellKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText читает вид клетки по названию
+func (k *CellKind) UnmarshalText(text []byte) error {
+	for kind := CellFloor; kind < cellKindCount; kind++ {
+		if kind.String() == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("неизвестный вид клетки %q", text)
+}
+
+// FaceSet множество чисел на гранях кубика, бит n-1 отвечает за число n
+type FaceSet uint8
+
+// AllFaces множество всех чисел от 1 до 6
+const AllFaces FaceSet = 1<<6 - 1
+
+// NewFaceSet создает множество из чисел
+func NewFaceSet(numbers ...int) FaceSet {
+	var s FaceSet
+	for _, n := range numbers {
+		s = s.Add(n)
+	}
+	return s
+}
+
+// invalidFace бит, которым помечается множество с числом вне 1..6
+const invalidFace FaceSet = 1 << 7
+
+// Add возвращает множество с добавленным числом; число вне 1..6 делает множество невалидным
+func (s FaceSet) Add(n int) FaceSet {
+	if n < 1 || n > 6 {
+		return s | invalidFace
+	}
+	return s | 1<<(n-1)
+}
+
+// Has проверяет, входит ли число в множество
+func (s FaceSet) Has(n int) bool {
+	return n >= 1 && n <= 6 && s&(1<<(n-1)) != 0
+}
+
+// Numbers возвращает числа множества по возрастанию
+func (s FaceSet) Numbers() []int {
+	var numbers []int
+	for n := 1; n <= 6; n++ {
+		if s.Has(n) {
+			numbers = append(numbers, n)
+		}
+	}
+	return numbers
+}
+
+// String возвращает числа множества подряд, например "16"
+func (s FaceSet) String() string {
+	var b strings.Builder
+	for _, n := range s.Numbers() {
+		b.WriteByte(byte('0' + n))
+	}
+	return b.String()
+}
+
+// MarshalJSON записывает множество списком чисел
+func (s FaceSet) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Numbers())
+}
+
+// UnmarshalJSON читает множество из списка чисел
+func (s *FaceSet) UnmarshalJSON(data []byte) error {
+	var numbers []int
+	if err := json.Unmarshal(data, &numbers); err != nil {
+		return err
+	}
+	*s = NewFaceSet(numbers...)
+	return nil
+}
+
+// Accepts проверяет, можно ли закатить на клетку кубик в ориентации die
+// (ориентация уже после переката на клетку)
+func (c Cell) Accepts(die Die) bool {
+	switch c.Kind {
+	case CellLock:
+		return die.Top == c.Number
+	case CellForbid:
+		return !c.Forbidden.Has(die.Top)
+	}
+	return true
+}
+
+// Enter возвращает игрока после въезда на клетку: поворотные клетки
+// разворачивают кубик на месте, телепорты переносят его на парную клетку,
+// остальные клетки оставляют игрока как есть
+func (c Cell) Enter(p Player) Player {
+	switch c.Kind {
+	case CellRotateCW:
+		p.Die.Rotate(true)
+	case CellRotateCCW:
+		p.Die.Rotate(false)
+	case CellTeleport:
+		p.X, p.Y = c.PairX, c.PairY
+	case CellTeleportReset:
+		p.X, p.Y = c.PairX, c.PairY
+		p.Die = NewDie()
+	}
+	return p
+}
+
+// isIce проверяет, что клетка (x, y) покрыта льдом
+func (l *Level) isIce(x, y int) bool {
+	return l.Cells[y][x].Kind == CellIce
+}
+
+// IsTeleport сообщает, является ли клетка телепортом любого вида
+func (c Cell) IsTeleport() bool {
+	return c.Kind == CellTeleport || c.Kind == CellTeleportReset
+}
+
+// IsSpecial сообщает, отличается ли клетка от обычного пола или стены
+func (c Cell) IsSpecial() bool {
+	return !c.IsWall && c.Kind != CellFloor
+}
+
+// validate проверяет параметры особой клетки
+func (c Cell) validate() error {
+	switch c.Kind {
+	case CellFloor:
+	case CellLock:
+		if c.Number < 1 || c.Number > 6 {
+			return fmt.Errorf("клетка (%d,%d): число замка должно быть от 1 до 6, получено %d", c.X, c.Y, c.Number)
+		}
+	case CellForbid:
+		if c.Forbidden == 0 || c.Forbidden&^AllFaces != 0 {
+			return fmt.Errorf("клетка (%d,%d): запрещенные числа должны быть от 1 до 6", c.X, c.Y)
+		}
+	case CellRotateCW, CellRotateCCW, CellTeleport, CellTeleportReset, CellIce:
+	default:
+		return fmt.Errorf("клетка (%d,%d): неизвестный вид %d", c.X, c.Y, c.Kind)
+	}
+	return nil
+}
+
+// Tiles возвращает особые клетки уровня построчно
+func (l *Level) Tiles() []Cell {
+	var tiles []Cell
+	for _, row := range l.Cells {
+		for _, cell := range row {
+			if cell.IsSpecial() {
+				tiles = append(tiles, cell)
+			}
+		}
+	}
+	return tiles
+}
+
+// setTile проверяет особую клетку и ставит ее на уровень вместо пола
+func (l *Level) setTile(tile Cell) error {
+	if !l.inBounds(tile.X, tile.Y) {
+		return fmt.Errorf("клетка (%d,%d) вне уровня", tile.X, tile.Y)
+	}
+	if l.Cells[tile.Y][tile.X].IsWall {
+		return fmt.Errorf("клетка (%d,%d) стена", tile.X, tile.Y)
+	}
+	if err := tile.validate(); err != nil {
+		return err
+	}
+	l.Cells[tile.Y][tile.X] = tile
+	return nil
+}
+
+// checkTeleports проверяет, что каждый телепорт связан с другим телепортом
+// того же вида, который ведет обратно, и что телепорт не стоит на финише
+func (l *Level) checkTeleports() error {
+	for _, tile := range l.Tiles() {
+		if !tile.IsTeleport() {
+			continue
+		}
+		if tile.X == l.Finish.X && tile.Y == l.Finish.Y {
+			return fmt.Errorf("телепорт (%d,%d) на финише", tile.X, tile.Y)
+		}
+		if !l.inBounds(tile.PairX, tile.PairY) || tile.PairX == tile.X && tile.PairY == tile.Y {
+			return fmt.Errorf("телепорт (%d,%d): неверная пара (%d,%d)", tile.X, tile.Y, tile.PairX, tile.PairY)
+		}
+		pair := l.Cells[tile.PairY][tile.PairX]
+		if pair.IsWall || pair.Kind != tile.Kind || pair.PairX != tile.X || pair.PairY != tile.Y {
+			return fmt.Errorf("телепорт (%d,%d) не связан с парой (%d,%d)", tile.X, tile.Y, tile.PairX, tile.PairY)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package engine
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestFaceSet(t *testing.T) {
+	s := NewFaceSet(6, 1, 6)
+	if !s.Has(1) || !s.Has(6) || s.Has(2) || s.Has(0) || s.Has(7) {
+		t.Errorf("NewFaceSet(6, 1, 6) = %08b", s)
+	}
+	if got := s.Numbers(); !reflect.DeepEqual(got, []int{1, 6}) {
+		t.Errorf("Numbers = %v, want [1 6]", got)
+	}
+	if got := s.String(); got != "16" {
+		t.Errorf("String = %q, want \"16\"", got)
+	}
+	if NewFaceSet(7)&^AllFaces == 0 {
+		t.Error("number 7 did not mark the set invalid")
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil || string(data) != "[1,6]" {
+		t.Fatalf("Marshal = %s, %v, want [1,6]", data, err)
+	}
+	var got FaceSet
+	if err := json.Unmarshal(data, &got); err != nil || got != s {
+		t.Errorf("Unmarshal = %08b, %v, want %08b", got, err, s)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"kubegame/engine"
+	"kubegame/render"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Расположение текстовых панелей на экране
+const (
+	settingsPanelX = 20
+	statusPanelX   = 320
+	panelY         = 20
+	panelLineStep  = 25
+)
+
+// RaylibRenderer рисует раскладку render через raylib
+type RaylibRenderer struct {
+	GridSize         int
+	OffsetX, OffsetY int
+}
+
+// cellPosition переводит координаты клетки в пиксели экрана
+func (r *RaylibRenderer) cellPosition(x, y int) (int, int) {
+	return r.OffsetX + x*r.GridSize, r.OffsetY + y*r.GridSize
+}
+
+// DrawCell закрашивает клетку пола
+func (r *RaylibRenderer) DrawCell(x, y int, fill color.RGBA) {
+	cellX, cellY := r.cellPosition(x, y)
+	rl.DrawRectangle(int32(cellX), int32(cellY), int32(r.GridSize), int32(r.GridSize), fill)
+	rl.DrawRectangleLines(int32(cellX), int32(cellY), int32(r.GridSize), int32(r.GridSize), rl.DarkGray)
+}
+
+// DrawWall рисует стену как закрашенную клетку
+func (r *RaylibRenderer) DrawWall(x, y int) {
+	cellX, cellY := r.cellPosition(x, y)
+	rl.DrawRectangle(int32(cellX), int32(cellY), int32(r.GridSize), int32(r.GridSize), rl.DarkBrown)
+	rl.DrawRectangleLines(int32(cellX), int32(cellY), int32(r.GridSize), int32(r.GridSize), rl.Black)
+}
+
+// DrawLabel пишет подпись по центру клетки
+func (r *RaylibRenderer) DrawLabel(x, y int, text string, c color.RGBA) {
+	cellX, cellY := r.cellPosition(x, y)
+	// Длинную подпись уменьшаем, чтобы она не вылезала за клетку
+	fontSize := int32(24)
+	textWidth := rl.MeasureText(text, fontSize)
+	for fontSize > 12 && int(textWidth) > r.GridSize-4 {
+		fontSize -= 2
+		textWidth = rl.MeasureText(text, fontSize)
+	}
+	textX := cellX + (r.GridSize-int(textWidth))/2
+	textY := cellY + (r.GridSize-int(fontSize))/2
+	rl.DrawText(text, int32(textX), int32(textY), fontSize, c)
+}
+
+// DrawArrow рисует треугольную стрелку направления в клетке
+func (r *RaylibRenderer) DrawArrow(x, y int, dir engine.Direction, c color.RGBA) {
+	cellX, cellY := r.cellPosition(x, y)
+	centerX := float32(cellX) + float32(r.GridSize)/2
+	centerY := float32(cellY) + float32(r.GridSize)/2
+	size := float32(r.GridSize) / 4
+	dx, dy := dir.Delta()
+	tip := rl.NewVector2(centerX+float32(dx)*size, centerY+float32(dy)*size)
+	baseA := rl.NewVector2(centerX-float32(dx)*size-float32(dy)*size, centerY-float32(dy)*size+float32(dx)*size)
+	baseB := rl.NewVector2(centerX-float32(dx)*size+float32(dy)*size, centerY-float32(dy)*size-float32(dx)*size)
+	// raylib рисует только треугольники с обходом против часовой стрелки,
+	// а обход зависит от направления, поэтому рисуем оба варианта
+	rl.DrawTriangle(tip, baseA, baseB, c)
+	rl.DrawTriangle(tip, baseB, baseA, c)
+}
+
+// DrawDie рисует кубик с визуализацией всех сторон
+func (r *RaylibRenderer) DrawDie(x, y int, die engine.Die) {
+	cellX, cellY := r.cellPosition(x, y)
+	size := r.GridSize
+	padding := 5
+	dieSize := size - 2*padding
+
+	drawX := cellX + padding
+	drawY := cellY + padding
+
+	// Рисуем основной кубик
+	rl.DrawRectangle(int32(drawX), int32(drawY), int32(dieSize), int32(dieSize), render.DieColor(die.CurrentTop))
+	rl.DrawRectangleLines(int32(drawX), int32(drawY), int32(dieSize), int32(dieSize), rl.Black)
+
+	// Рисуем число на верхней стороне
+	text := fmt.Sprintf("%d", die.CurrentTop)
+	fontSize := int32(24)
+	textWidth := rl.MeasureText(text, fontSize)
+	textX := drawX + (dieSize-int(textWidth))/2
+	textY := drawY + (dieSize-24)/2
+	rl.DrawText(text, int32(textX), int32(textY), fontSize, rl.White)
+
+	// Рисуем стороны кубика как цветные полоски
+	stripHeight := 4
+	margin := 2
+
+	// Левая полоска (Left сторона)
+	rl.DrawRectangle(int32(drawX-margin-stripHeight), int32(drawY+margin), int32(stripHeight), int32(dieSize-2*margin), render.DieColor(die.Left))
+
+	// Правая полоска (Right сторона)
+	rl.DrawRectangle(int32(drawX+dieSize+margin), int32(drawY+margin), int32(stripHeight), int32(dieSize-2*margin), render.DieColor(die.Right))
+
+	// Передняя полоска (Front сторона) - внизу
+	rl.DrawRectangle(int32(drawX+margin), int32(drawY+dieSize+margin+2), int32(dieSize-2*margin), int32(stripHeight), render.DieColor(die.Front))
+
+	// Задняя полоска (Back сторона) - вверху
+	rl.DrawRectangle(int32(drawX+margin), int32(drawY-margin-stripHeight-2), int32(dieSize-2*margin), int32(stripHeight), render.DieColor(die.Back))
+
+	// Подписи к полоскам
+	fontSizeSmall := int32(12)
+	rl.DrawText(fmt.Sprintf("%d", die.Left), int32(drawX-margin-stripHeight-15), int32(drawY+dieSize/2-6), fontSizeSmall, rl.Black)
+	rl.DrawText(fmt.Sprintf("%d", die.Right), int32(drawX+dieSize+margin+stripHeight+2), int32(drawY+dieSize/2-6), fontSizeSmall, rl.Black)
+	rl.DrawText(fmt.Sprintf("%d", die.Front), int32(drawX+dieSize/2-6), int32(drawY+dieSize+margin+stripHeight+5), fontSizeSmall, rl.Black)
+	rl.DrawText(fmt.Sprintf("%d", die.Back), int32(drawX+dieSize/2-6), int32(drawY-margin-stripHeight-2-stripHeight-5), fontSizeSmall, rl.Black)
+}
+
+// DrawPanel рисует белый фон панели настроек, остальные панели без фона
+func (r *RaylibRenderer) DrawPanel(panel render.Panel, lines int) {
+	if panel != render.PanelSettings {
+		return
+	}
+	height := int32(lines*panelLineStep + 10)
+	rl.DrawRectangle(10, 10, 300, height, rl.White)
+	rl.DrawRectangleLines(10, 10, 300, height, rl.Black)
+}
+
+// DrawText пишет строку панели; сообщение рисуется по центру экрана на зеленом фоне
+func (r *RaylibRenderer) DrawText(panel render.Panel, line int, text string, style render.TextStyle) {
+	fontSize := int32(style.Size)
+
+	switch panel {
+	case render.PanelSettings:
+		rl.DrawText(text, settingsPanelX, int32(panelY+line*panelLineStep), fontSize, style.Color)
+	case render.PanelStatus:
+		rl.DrawText(text, statusPanelX, int32(panelY+line*panelLineStep), fontSize, style.Color)
+	case render.PanelMessage:
+		textWidth := rl.MeasureText(text, fontSize)
+		textX := (ScreenWidth - int(textWidth)) / 2
+		textY := ScreenHeight/2 - 15 + line*60
+
+		rl.DrawRectangle(int32(textX-10), int32(textY-10), int32(textWidth+20), 60, rl.Green)
+		rl.DrawRectangleLines(int32(textX-10), int32(textY-10), int32(textWidth+20), 60, rl.Black)
+		rl.DrawText(text, int32(textX), int32(textY), fontSize, style.Color)
+	}
+}
@@ -0,0 +1,347 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"kubegame/engine"
+)
+
+// textLine строка текста панели
+type textLine struct {
+	text  string
+	style TextStyle
+}
+
+// DrawLevel рисует поле уровня целиком: сетку, стены, финиш, подсказку и кубик;
+// hint равен nil, если подсказки нет
+func DrawLevel(r Renderer, level *engine.Level, hint *engine.Hint) {
+	DrawGrid(r, level.Size.Width, level.Size.Height)
+	DrawMazeWalls(r, level.Cells)
+	DrawTiles(r, level.Cells)
+	DrawFinish(r, level.Finish.X, level.Finish.Y, level.Finish.Number)
+	DrawHint(r, level, hint)
+	r.DrawDie(level.Player.X, level.Player.Y, level.Player.Die)
+}
+
+// DrawGrid рисует фон сетки
+func DrawGrid(r Renderer, width, height int) {
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if (x+y)%2 == 0 {
+				r.DrawCell(x, y, LightGray)
+			} else {
+				r.DrawCell(x, y, Gray)
+			}
+		}
+	}
+}
+
+// DrawMazeWalls рисует стены лабиринта как полные клетки
+func DrawMazeWalls(r Renderer, cells [][]engine.Cell) {
+	for y := 0; y < len(cells); y++ {
+		for x := 0; x < len(cells[y]); x++ {
+			if cells[y][x].IsWall {
+				r.DrawWall(x, y)
+			}
+		}
+	}
+}
+
+// MaxLabelLength самая длинная подпись, которая помещается в клетку
+// во всех интерфейсах (в терминале клетка шириной TerminalCellWidth)
+const MaxLabelLength = 3
+
+// legendKeys буквы легенды запретов; их хватает на все 42 множества
+// из трех и более чисел
+const legendKeys = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// forbidLabels возвращает подписи запретов: короткое множество пишется
+// целиком, длинное заменяется буквой, которая расшифровывается в легенде.
+// Буквы раздаются по порядку клеток, одинаковым запретам достается одна буква
+func forbidLabels(cells [][]engine.Cell) map[engine.FaceSet]string {
+	labels := map[engine.FaceSet]string{}
+	letters := 0
+	for _, row := range cells {
+		for _, cell := range row {
+			if cell.IsWall || cell.Kind != engine.CellForbid {
+				continue
+			}
+			if _, ok := labels[cell.Forbidden]; ok {
+				continue
+			}
+			label := "!" + cell.Forbidden.String()
+			if len(label) > MaxLabelLength {
+				label = "!" + legendKeys[letters:letters+1]
+				letters++
+			}
+			labels[cell.Forbidden] = label
+		}
+	}
+	return labels
+}
+
+// forbidLegend возвращает строки легенды для запретов, подписанных буквой,
+// в порядке букв
+func forbidLegend(cells [][]engine.Cell) []string {
+	labels := forbidLabels(cells)
+	legend := make([]string, 0, len(labels))
+	for set, label := range labels {
+		if label == "!"+set.String() {
+			continue
+		}
+		numbers := strings.Split(set.String(), "")
+		legend = append(legend, fmt.Sprintf("%s: top must not be %s", label, strings.Join(numbers, ", ")))
+	}
+	sort.Strings(legend)
+	return legend
+}
+
+// DrawTiles рисует особые клетки пола
+func DrawTiles(r Renderer, cells [][]engine.Cell) {
+	forbid := forbidLabels(cells)
+	// Пары телепортов нумеруются по порядку, номер виден на обеих клетках пары
+	pairs := map[[2]int]int{}
+	for y := 0; y < len(cells); y++ {
+		for x := 0; x < len(cells[y]); x++ {
+			cell := cells[y][x]
+			switch cell.Kind {
+			case engine.CellLock:
+				// Замок окрашен в цвет нужного числа, как грань кубика
+				r.DrawCell(x, y, Fade(DieColor(cell.Number), 0.5))
+				r.DrawLabel(x, y, fmt.Sprintf("=%d", cell.Number), Black)
+			case engine.CellForbid:
+				// Запрет: красноватая клетка, запрещенные числа или буква легенды после "!"
+				r.DrawCell(x, y, Fade(Red, 0.35))
+				r.DrawLabel(x, y, forbid[cell.Forbidden], Black)
+			case engine.CellRotateCW, engine.CellRotateCCW:
+				// Поворот: сиреневая клетка с направлением поворота
+				label := "cw"
+				if cell.Kind == engine.CellRotateCCW {
+					label = "ccw"
+				}
+				r.DrawCell(x, y, Fade(Purple, 0.35))
+				r.DrawLabel(x, y, label, Black)
+			case engine.CellTeleport, engine.CellTeleportReset:
+				// Телепорт: синий переносит кубик как есть, оранжевый сбрасывает ориентацию
+				number, ok := pairs[[2]int{x, y}]
+				if !ok {
+					number = len(pairs) + 1
+					pairs[[2]int{cell.PairX, cell.PairY}] = number
+				}
+				c := Blue
+				if cell.Kind == engine.CellTeleportReset {
+					c = Orange
+				}
+				r.DrawCell(x, y, Fade(c, 0.35))
+				r.DrawLabel(x, y, fmt.Sprintf("T%d", number), Black)
+			case engine.CellIce:
+				// Лед: почти белая клетка
+				r.DrawCell(x, y, Fade(White, 0.7))
+				r.DrawLabel(x, y, "~", DarkBlue)
+			}
+		}
+	}
+}
+
+// DrawFinish рисует финишную клетку с требуемым числом
+func DrawFinish(r Renderer, x, y int, number int) {
+	r.DrawCell(x, y, Gold)
+	r.DrawLabel(x, y, fmt.Sprintf("%d", number), Black)
+}
+
+// DrawHint подсвечивает следующий оптимальный ход, а в режиме HintPath весь оставшийся путь
+func DrawHint(r Renderer, level *engine.Level, hint *engine.Hint) {
+	if hint == nil || hint.Mode == engine.HintOff || !hint.Result.Solvable {
+		return
+	}
+
+	current := level.Player
+	for i, dir := range hint.Result.Moves {
+		if i > 0 && hint.Mode != engine.HintPath {
+			break
+		}
+		current, _ = level.NextState(current, dir)
+
+		// Следующий ход выделяем ярче остального пути
+		alpha := float32(0.3)
+		if i == 0 {
+			alpha = 0.7
+		}
+		r.DrawCell(current.X, current.Y, Fade(SkyBlue, alpha))
+		r.DrawArrow(current.X, current.Y, dir, DarkBlue)
+	}
+}
+
+// SizeKeys подсказки клавиш размера, которые у фронтендов различаются
+type SizeKeys struct {
+	Height string // клавиши высоты, например "Q-I"
+	Packs  bool   // есть ли в интерфейсе меню наборов уровней
+}
+
+// DrawLevelSizeUI рисует UI для выбора размера уровня
+func DrawLevelSizeUI(r Renderer, size engine.LevelSize, keys SizeKeys) {
+	lines := []textLine{
+		{"Level Size:", TextStyle{20, Black}},
+		{fmt.Sprintf("Width: %d", size.Width), TextStyle{18, Black}},
+		{fmt.Sprintf("Height: %d", size.Height), TextStyle{18, Black}},
+		{fmt.Sprintf("Maze: %s (M)", size.Algorithm), TextStyle{18, Black}},
+		bandLine(size),
+		{fmt.Sprintf("1-9: Width  |  %s: Height", keys.Height), TextStyle{14, DarkGray}},
+		{"R: New level", TextStyle{14, DarkGray}},
+	}
+	if keys.Packs {
+		lines = append(lines, textLine{"P: Level packs", TextStyle{14, DarkGray}})
+	}
+
+	r.DrawPanel(PanelSettings, len(lines))
+	for i, line := range lines {
+		r.DrawText(PanelSettings, i, line.text, line.style)
+	}
+}
+
+// bandLine возвращает строку желаемой сложности; диапазон, недостижимый
+// на уровне такого размера, помечается
+func bandLine(size engine.LevelSize) textLine {
+	text := fmt.Sprintf("Difficulty: %s (L)", size.Target.Band)
+	if !size.Reaches(size.Target.Band) {
+		return textLine{text + " n/a", TextStyle{18, Red}}
+	}
+	return textLine{text, TextStyle{18, Black}}
+}
+
+// difficultyText возвращает строку сложности уровня с пометкой,
+// если генератор не попал в выбранный диапазон
+func difficultyText(level *engine.Level) string {
+	text := fmt.Sprintf("Difficulty: %s  Best: %d moves", level.Difficulty, level.OptimalMoves)
+	if !level.MeetsTarget() {
+		text += "  (target missed)"
+	}
+	return text
+}
+
+// DrawUI рисует состояние игры, подсказку и сообщение о победе;
+// keys подсказывают клавиши размера этого фронтенда
+func DrawUI(r Renderer, level *engine.Level, hint *engine.Hint, keys SizeKeys) {
+	status := []textLine{
+		// Текущее число кубика и число на финише
+		{fmt.Sprintf("Current: %d", level.Player.Die.CurrentTop), TextStyle{24, Black}},
+		{fmt.Sprintf("Target: %d", level.Finish.Number), TextStyle{24, Black}},
+		// Позиция игрока, размер и сложность уровня
+		{fmt.Sprintf("Pos: (%d,%d)", level.Player.X, level.Player.Y), TextStyle{18, DarkGray}},
+		{fmt.Sprintf("Size: %dx%d  Seed: %d", level.Size.Width, level.Size.Height, level.Seed), TextStyle{18, DarkGray}},
+		{difficultyText(level), TextStyle{18, DarkGray}},
+		// Инструкции
+		{fmt.Sprintf("WASD/Arrows: Move | R: New level | 1-9/%s: Size | H: Hint", keys.Height), TextStyle{16, DarkGray}},
+		{fmt.Sprintf("Z/Backspace: Undo | X: Redo | Shift+R: Restart  Moves: %d", len(level.History)), TextStyle{16, DarkGray}},
+		{"C: Copy level code | V: Paste level code", TextStyle{16, DarkGray}},
+	}
+
+	// Подсказка
+	if hint != nil && hint.Mode != engine.HintOff && !level.Won {
+		hintText := "Hint: no solution from here"
+		if hint.Result.Solvable && len(hint.Result.Moves) > 0 {
+			hintText = fmt.Sprintf("Hint: %s (%d moves left)", hint.Result.Moves[0], len(hint.Result.Moves))
+		}
+		status = append(status, textLine{hintText, TextStyle{18, DarkBlue}})
+	}
+
+	// Легенда длинных запретов, которые на поле подписаны буквой
+	for _, line := range forbidLegend(level.Cells) {
+		status = append(status, textLine{line, TextStyle{16, DarkGray}})
+	}
+
+	for i, line := range status {
+		r.DrawText(PanelStatus, i, line.text, line.style)
+	}
+
+	// Сообщение о победе
+	if level.Won {
+		r.DrawText(PanelMessage, 0, "YOU WIN! R: new level | Shift+R: play again", TextStyle{30, White})
+	}
+}
+
+// DrawResumePrompt предлагает продолжить сохраненную игру
+func DrawResumePrompt(r Renderer) {
+	r.DrawText(PanelMessage, 0, "Saved game found. Enter: resume | N: new game", TextStyle{30, White})
+}
+
+// DrawReplayUI рисует панель воспроизведения записи вместо панели настроек
+func DrawReplayUI(r Renderer, p *engine.ReplayPlayer) {
+	state := "Paused"
+	if p.Playing {
+		state = "Playing"
+	}
+	lines := []textLine{
+		{"Replay:", TextStyle{20, Black}},
+		{fmt.Sprintf("%s  %d/%d", state, p.Pos, p.Replay.Len()), TextStyle{18, Black}},
+		{fmt.Sprintf("Speed: %gx", p.Speed/engine.DefaultReplaySpeed), TextStyle{18, Black}},
+		{"Space: Play/Pause", TextStyle{14, DarkGray}},
+		{"Left/Right: Step  |  +/-: Speed", TextStyle{14, DarkGray}},
+		{"Home: Rewind  |  Esc: Quit", TextStyle{14, DarkGray}},
+	}
+
+	r.DrawPanel(PanelSettings, len(lines))
+	for i, line := range lines {
+		r.DrawText(PanelSettings, i, line.text, line.style)
+	}
+}
+
+// DrawPackMenu рисует меню выбора набора уровней и уровня в нем
+func DrawPackMenu(r Renderer, packs []*engine.Pack, progress *engine.Progress, selected, selectedLevel int) {
+	lines := []textLine{{"Level Packs:", TextStyle{20, Black}}}
+	for i, pack := range packs {
+		style := TextStyle{18, DarkGray}
+		marker := "  "
+		if i == selected {
+			style, marker = TextStyle{18, Black}, "> "
+		}
+		text := fmt.Sprintf("%s%s  %d/%d", marker, pack.Name, progress.Completed[pack.ID], len(pack.Levels))
+		lines = append(lines, textLine{text, style})
+	}
+	if len(packs) == 0 {
+		lines = append(lines, textLine{"No packs found", TextStyle{18, DarkGray}})
+	} else {
+		pack := packs[selected]
+		lines = append(lines,
+			textLine{fmt.Sprintf("by %s", pack.Author), TextStyle{14, DarkGray}},
+			textLine{fmt.Sprintf("Level: %d (unlocked %d)", selectedLevel+1, progress.Unlocked(pack)), TextStyle{18, Black}},
+		)
+	}
+	lines = append(lines,
+		textLine{"Up/Down: Pack  |  Left/Right: Level", TextStyle{14, DarkGray}},
+		textLine{"Enter: Play  |  P: Back", TextStyle{14, DarkGray}},
+	)
+
+	r.DrawPanel(PanelSettings, len(lines))
+	for i, line := range lines {
+		r.DrawText(PanelSettings, i, line.text, line.style)
+	}
+}
+
+// DrawPackUI рисует панель текущего уровня набора вместо панели настроек
+// и после победы подсказывает переход к следующему уровню
+func DrawPackUI(r Renderer, pack *engine.Pack, index int, progress *engine.Progress, won bool) {
+	lines := []textLine{
+		{"Pack:", TextStyle{20, Black}},
+		{pack.Name, TextStyle{18, Black}},
+		{fmt.Sprintf("Level %d of %d", index+1, len(pack.Levels)), TextStyle{18, Black}},
+		{fmt.Sprintf("Completed: %d", progress.Completed[pack.ID]), TextStyle{18, Black}},
+		{"N: Next level  |  P: Packs", TextStyle{14, DarkGray}},
+		{"R: Leave pack (new random level)", TextStyle{14, DarkGray}},
+	}
+
+	r.DrawPanel(PanelSettings, len(lines))
+	for i, line := range lines {
+		r.DrawText(PanelSettings, i, line.text, line.style)
+	}
+
+	if won {
+		next := "N: next level | P: packs"
+		if index+1 >= len(pack.Levels) {
+			next = "Pack complete! P: packs"
+		}
+		r.DrawText(PanelMessage, 1, next, TextStyle{30, White})
+	}
+}
@@ -0,0 +1,414 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+
+	"kubegame/engine"
+)
+
+// recorder записывает вызовы Renderer строками, чтобы сравнивать раскладку
+type recorder struct {
+	calls []string
+}
+
+func (r *recorder) add(format string, args ...any) {
+	r.calls = append(r.calls, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) DrawCell(x, y int, fill color.RGBA) {
+	r.add("cell %d,%d %s", x, y, colorName(fill))
+}
+
+func (r *recorder) DrawWall(x, y int) {
+	r.add("wall %d,%d", x, y)
+}
+
+func (r *recorder) DrawLabel(x, y int, text string, c color.RGBA) {
+	r.add("label %d,%d %s %s", x, y, text, colorName(c))
+}
+
+func (r *recorder) DrawArrow(x, y int, dir engine.Direction, c color.RGBA) {
+	r.add("arrow %d,%d %s %s", x, y, dir, colorName(c))
+}
+
+func (r *recorder) DrawDie(x, y int, die engine.Die) {
+	r.add("die %d,%d top %d", x, y, die.CurrentTop)
+}
+
+func (r *recorder) DrawPanel(panel Panel, lines int) {
+	r.add("panel %d %d", panel, lines)
+}
+
+func (r *recorder) DrawText(panel Panel, line int, text string, style TextStyle) {
+	r.add("text %d/%d %s [%d %s]", panel, line, text, style.Size, colorName(style.Color))
+}
+
+// colorName возвращает имя цвета палитры и прозрачность, если она не полная
+func colorName(c color.RGBA) string {
+	names := map[color.RGBA]string{
+		LightGray: "LightGray", Gray: "Gray", DarkGray: "DarkGray",
+		Yellow: "Yellow", Gold: "Gold", Orange: "Orange", Red: "Red",
+		Green: "Green", SkyBlue: "SkyBlue", Blue: "Blue", DarkBlue: "DarkBlue",
+		Purple: "Purple", DarkBrown: "DarkBrown", White: "White", Black: "Black",
+	}
+	alpha := c.A
+	c.A = 255
+	name, ok := names[c]
+	if !ok {
+		name = fmt.Sprintf("%v", c)
+	}
+	if alpha != 255 {
+		name += fmt.Sprintf("/%d", alpha)
+	}
+	return name
+}
+
+// testLevel читает уровень в текстовом формате engine
+func testLevel(t *testing.T, text string) *engine.Level {
+	t.Helper()
+	l, err := engine.ParseLevel(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("ParseLevel: %v", err)
+	}
+	return &l
+}
+
+func TestDrawLevel(t *testing.T) {
+	level := testLevel(t, "finish 6\nS.F\n.#.\n")
+	hint := &engine.Hint{Mode: engine.HintPath}
+	hint.Update(level)
+
+	r := &recorder{}
+	DrawLevel(r, level, hint)
+	want := []string{
+		"cell 0,0 LightGray", "cell 1,0 Gray", "cell 2,0 LightGray",
+		"cell 0,1 Gray", "cell 1,1 LightGray", "cell 2,1 Gray",
+		"wall 1,1",
+		"cell 2,0 Gold", "label 2,0 6 Black",
+		"cell 1,0 SkyBlue/178", "arrow 1,0 Right DarkBlue",
+		"cell 2,0 SkyBlue/76", "arrow 2,0 Right DarkBlue",
+		"die 0,0 top 1",
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("DrawLevel() calls:\n%q\nwant:\n%q", r.calls, want)
+	}
+}
+
+func TestDrawTiles(t *testing.T) {
+	level := testLevel(t, "finish 6\ntile 0 0 lock 1\nS3F\n.#5\n")
+
+	r := &recorder{}
+	DrawTiles(r, level.Cells)
+	want := []string{
+		"cell 0,0 Red/127", "label 0,0 =1 Black",
+		"cell 1,0 Yellow/127", "label 1,0 =3 Black",
+		"cell 2,1 Blue/127", "label 2,1 =5 Black",
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("DrawTiles() calls:\n%q\nwant:\n%q", r.calls, want)
+	}
+
+	// Повороты подписаны направлением
+	level = testLevel(t, "finish 4\nS)(F\n")
+	r = &recorder{}
+	DrawTiles(r, level.Cells)
+	want = []string{
+		"cell 1,0 Purple/89", "label 1,0 cw Black",
+		"cell 2,0 Purple/89", "label 2,0 ccw Black",
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("DrawTiles() with rotators calls:\n%q\nwant:\n%q", r.calls, want)
+	}
+
+	// Обе клетки пары телепортов подписаны одним номером
+	level = testLevel(t, "finish 1\nteleport 1 0 3 0\nteleport 0 1 2 1 reset\nS...F\n.....\n")
+	r = &recorder{}
+	DrawTiles(r, level.Cells)
+	want = []string{
+		"cell 1,0 Blue/89", "label 1,0 T1 Black",
+		"cell 3,0 Blue/89", "label 3,0 T1 Black",
+		"cell 0,1 Orange/89", "label 0,1 T2 Black",
+		"cell 2,1 Orange/89", "label 2,1 T2 Black",
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("DrawTiles() with teleports calls:\n%q\nwant:\n%q", r.calls, want)
+	}
+
+	// Лед подписан волной
+	level = testLevel(t, "finish 1\nS~F\n")
+	r = &recorder{}
+	DrawTiles(r, level.Cells)
+	want = []string{"cell 1,0 White/178", "label 1,0 ~ DarkBlue"}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("DrawTiles() with ice calls:\n%q\nwant:\n%q", r.calls, want)
+	}
+
+	// Длинный запрет подписан буквой легенды
+	level = testLevel(t, forbidLevel)
+	r = &recorder{}
+	DrawTiles(r, level.Cells)
+	want = []string{
+		"cell 1,0 Red/89", "label 1,0 !A Black",
+		"cell 2,0 Red/89", "label 2,0 !1 Black",
+		"cell 3,0 Red/89", "label 3,0 !16 Black",
+		"cell 0,1 Red/89", "label 0,1 !B Black",
+		"cell 2,1 Red/89", "label 2,1 !A Black",
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("DrawTiles() with forbids calls:\n%q\nwant:\n%q", r.calls, want)
+	}
+}
+
+// forbidLevel уровень с короткими и длинными запретами
+const forbidLevel = `finish 1
+forbid 1 0 1 2 3
+forbid 3 0 1 6
+forbid 0 1 4 5 6
+forbid 2 1 1 2 3
+S.a.F
+.....
+`
+
+func TestForbidLabels(t *testing.T) {
+	level := testLevel(t, forbidLevel)
+
+	// Длинные запреты получают буквы по порядку клеток, одинаковые — одну букву
+	got := forbidLabels(level.Cells)
+	want := map[engine.FaceSet]string{
+		engine.NewFaceSet(1, 2, 3): "!A",
+		engine.NewFaceSet(1):       "!1",
+		engine.NewFaceSet(1, 6):    "!16",
+		engine.NewFaceSet(4, 5, 6): "!B",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("forbidLabels() = %v, want %v", got, want)
+	}
+
+	legend := forbidLegend(level.Cells)
+	wantLegend := []string{"!A: top must not be 1, 2, 3", "!B: top must not be 4, 5, 6"}
+	if !reflect.DeepEqual(legend, wantLegend) {
+		t.Errorf("forbidLegend() = %q, want %q", legend, wantLegend)
+	}
+}
+
+func TestDrawHint(t *testing.T) {
+	level := testLevel(t, "finish 6\nS.F\n.#.\n")
+	tests := []struct {
+		name string
+		hint *engine.Hint
+		want []string
+	}{
+		{"none", nil, nil},
+		{"off", &engine.Hint{}, nil},
+		{"next", &engine.Hint{Mode: engine.HintNext}, []string{"cell 1,0 SkyBlue/178", "arrow 1,0 Right DarkBlue"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.hint != nil {
+				tt.hint.Update(level)
+			}
+			r := &recorder{}
+			DrawHint(r, level, tt.hint)
+			if !reflect.DeepEqual(r.calls, tt.want) {
+				t.Errorf("DrawHint() calls = %q, want %q", r.calls, tt.want)
+			}
+		})
+	}
+
+	// Из состояния без решения подсказка ничего не рисует
+	level = testLevel(t, "finish 6\nS.F\n.##\n")
+	level.Player = engine.Player{X: 2, Y: 0, Die: engine.NewDie()}
+	hint := &engine.Hint{Mode: engine.HintPath}
+	hint.Update(level)
+	r := &recorder{}
+	DrawHint(r, level, hint)
+	if len(r.calls) != 0 {
+		t.Errorf("DrawHint() from an unsolvable state calls = %q, want none", r.calls)
+	}
+}
+
+func TestDrawLevelSizeUI(t *testing.T) {
+	size := engine.LevelSize{Width: 15, Height: 10, Algorithm: engine.AlgorithmCaves}
+	size.Target.Band = engine.BandExpert
+
+	r := &recorder{}
+	DrawLevelSizeUI(r, size, SizeKeys{Height: "Q,E,T-I"})
+	want := []string{
+		"panel 0 7",
+		"text 0/0 Level Size: [20 Black]",
+		"text 0/1 Width: 15 [18 Black]",
+		"text 0/2 Height: 10 [18 Black]",
+		"text 0/3 Maze: Caves (M) [18 Black]",
+		"text 0/4 Difficulty: Expert (L) [18 Black]",
+		"text 0/5 1-9: Width  |  Q,E,T-I: Height [14 DarkGray]",
+		"text 0/6 R: New level [14 DarkGray]",
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("DrawLevelSizeUI() calls:\n%q\nwant:\n%q", r.calls, want)
+	}
+
+	// Диапазон, который генератор не достигает на таком размере, помечается
+	size.Algorithm = engine.AlgorithmScatter
+	r = &recorder{}
+	DrawLevelSizeUI(r, size, SizeKeys{Height: "Q-I"})
+	if got, want := r.calls[5], "text 0/4 Difficulty: Expert (L) n/a [18 Red]"; got != want {
+		t.Errorf("unreachable band line = %q, want %q", got, want)
+	}
+
+	// Клавиша меню наборов показывается, только если меню есть
+	r = &recorder{}
+	DrawLevelSizeUI(r, size, SizeKeys{Height: "Q-I", Packs: true})
+	if got, want := r.calls[0], "panel 0 8"; got != want {
+		t.Errorf("DrawLevelSizeUI() with packs starts with %q, want %q", got, want)
+	}
+	if got, want := r.calls[len(r.calls)-1], "text 0/7 P: Level packs [14 DarkGray]"; got != want {
+		t.Errorf("DrawLevelSizeUI() with packs ends with %q, want %q", got, want)
+	}
+}
+
+func TestDrawUI(t *testing.T) {
+	level := testLevel(t, "finish 6\nS.F\n.#.\n")
+	level.Size.Target.Band = engine.BandExpert
+	level.Seed = 7
+	level.TryMove(engine.Down)
+	hint := &engine.Hint{Mode: engine.HintNext}
+	hint.Update(level)
+
+	r := &recorder{}
+	DrawUI(r, level, hint, SizeKeys{Height: "Q,E,T-I"})
+	want := []string{
+		"text 1/0 Current: 2 [24 Black]",
+		"text 1/1 Target: 6 [24 Black]",
+		"text 1/2 Pos: (0,1) [18 DarkGray]",
+		"text 1/3 Size: 3x2  Seed: 7 [18 DarkGray]",
+		fmt.Sprintf("text 1/4 Difficulty: %s  Best: 2 moves  (target missed) [18 DarkGray]", level.Difficulty),
+		"text 1/5 WASD/Arrows: Move | R: New level | 1-9/Q,E,T-I: Size | H: Hint [16 DarkGray]",
+		"text 1/6 Z/Backspace: Undo | X: Redo | Shift+R: Restart  Moves: 1 [16 DarkGray]",
+		"text 1/7 C: Copy level code | V: Paste level code [16 DarkGray]",
+		"text 1/8 Hint: Up (3 moves left) [18 DarkBlue]",
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("DrawUI() calls:\n%q\nwant:\n%q", r.calls, want)
+	}
+
+	// После победы подсказка скрыта, а поверх поля выводится сообщение
+	level.Undo()
+	level.TryMove(engine.Right)
+	level.TryMove(engine.Right)
+	r = &recorder{}
+	DrawUI(r, level, hint, SizeKeys{Height: "Q,E,T-I"})
+	last := r.calls[len(r.calls)-1]
+	if want := "text 2/0 YOU WIN! R: new level | Shift+R: play again [30 White]"; !level.Won || last != want {
+		t.Errorf("DrawUI() after a win ends with %q, want %q", last, want)
+	}
+}
+
+func TestDrawUIForbidLegend(t *testing.T) {
+	level := testLevel(t, forbidLevel)
+
+	r := &recorder{}
+	DrawUI(r, level, nil, SizeKeys{Height: "Q-I"})
+	got := r.calls[len(r.calls)-2:]
+	want := []string{
+		"text 1/8 !A: top must not be 1, 2, 3 [16 DarkGray]",
+		"text 1/9 !B: top must not be 4, 5, 6 [16 DarkGray]",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DrawUI() ends with %q, want the legend %q", got, want)
+	}
+}
+
+func TestDrawReplayUI(t *testing.T) {
+	level := testLevel(t, "finish 6\nS.F\n.#.\n")
+	replay := engine.NewReplay(level)
+	replay.Record(engine.MoveAction(engine.Right))
+	replay.Record(engine.MoveAction(engine.Right))
+	player := engine.NewReplayPlayer(replay)
+	player.Step()
+	player.Speed *= 2
+
+	r := &recorder{}
+	DrawReplayUI(r, player)
+	want := []string{
+		"panel 0 6",
+		"text 0/0 Replay: [20 Black]",
+		"text 0/1 Paused  1/2 [18 Black]",
+		"text 0/2 Speed: 2x [18 Black]",
+		"text 0/3 Space: Play/Pause [14 DarkGray]",
+		"text 0/4 Left/Right: Step  |  +/-: Speed [14 DarkGray]",
+		"text 0/5 Home: Rewind  |  Esc: Quit [14 DarkGray]",
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("DrawReplayUI() calls:\n%q\nwant:\n%q", r.calls, want)
+	}
+}
+
+func TestDrawPackMenu(t *testing.T) {
+	packs := []*engine.Pack{
+		{ID: "tutorial", Name: "Tutorial", Author: "kubegame", Levels: []string{"01.txt", "02.txt", "03.txt"}},
+		{ID: "extra", Name: "Extra", Author: "guest", Levels: []string{"01.txt"}},
+	}
+	progress := &engine.Progress{Completed: map[string]int{"tutorial": 1}}
+
+	r := &recorder{}
+	DrawPackMenu(r, packs, progress, 0, 1)
+	want := []string{
+		"panel 0 7",
+		"text 0/0 Level Packs: [20 Black]",
+		"text 0/1 > Tutorial  1/3 [18 Black]",
+		"text 0/2   Extra  0/1 [18 DarkGray]",
+		"text 0/3 by kubegame [14 DarkGray]",
+		"text 0/4 Level: 2 (unlocked 2) [18 Black]",
+		"text 0/5 Up/Down: Pack  |  Left/Right: Level [14 DarkGray]",
+		"text 0/6 Enter: Play  |  P: Back [14 DarkGray]",
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("DrawPackMenu() calls:\n%q\nwant:\n%q", r.calls, want)
+	}
+
+	r = &recorder{}
+	DrawPackMenu(r, nil, progress, 0, 0)
+	if got, want := r.calls[2], "text 0/1 No packs found [18 DarkGray]"; got != want {
+		t.Errorf("DrawPackMenu() without packs = %q, want %q", got, want)
+	}
+}
+
+func TestDrawPackUI(t *testing.T) {
+	pack := &engine.Pack{ID: "tutorial", Name: "Tutorial", Levels: []string{"01.txt", "02.txt"}}
+	progress := &engine.Progress{Completed: map[string]int{"tutorial": 1}}
+
+	r := &recorder{}
+	DrawPackUI(r, pack, 0, progress, false)
+	want := []string{
+		"panel 0 6",
+		"text 0/0 Pack: [20 Black]",
+		"text 0/1 Tutorial [18 Black]",
+		"text 0/2 Level 1 of 2 [18 Black]",
+		"text 0/3 Completed: 1 [18 Black]",
+		"text 0/4 N: Next level  |  P: Packs [14 DarkGray]",
+		"text 0/5 R: Leave pack (new random level) [14 DarkGray]",
+	}
+	if !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("DrawPackUI() calls:\n%q\nwant:\n%q", r.calls, want)
+	}
+
+	// После победы подсказывается следующий уровень, а на последнем — конец набора
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{0, "text 2/1 N: next level | P: packs [30 White]"},
+		{1, "text 2/1 Pack complete! P: packs [30 White]"},
+	}
+	for _, tt := range tests {
+		r = &recorder{}
+		DrawPackUI(r, pack, tt.index, progress, true)
+		if last := r.calls[len(r.calls)-1]; last != tt.want {
+			t.Errorf("DrawPackUI(index %d) after a win ends with %q, want %q", tt.index, last, tt.want)
+		}
+	}
+}
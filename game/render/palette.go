@@ -0,0 +1,48 @@
+package render
+
+import "image/color"
+
+// Палитра совпадает со стандартными цветами raylib
+var (
+	LightGray = color.RGBA{200, 200, 200, 255}
+	Gray      = color.RGBA{130, 130, 130, 255}
+	DarkGray  = color.RGBA{80, 80, 80, 255}
+	Yellow    = color.RGBA{253, 249, 0, 255}
+	Gold      = color.RGBA{255, 203, 0, 255}
+	Orange    = color.RGBA{255, 161, 0, 255}
+	Red       = color.RGBA{230, 41, 55, 255}
+	Green     = color.RGBA{0, 228, 48, 255}
+	SkyBlue   = color.RGBA{102, 191, 255, 255}
+	Blue      = color.RGBA{0, 121, 241, 255}
+	DarkBlue  = color.RGBA{0, 82, 172, 255}
+	Purple    = color.RGBA{200, 122, 255, 255}
+	DarkBrown = color.RGBA{76, 63, 47, 255}
+	White     = color.RGBA{255, 255, 255, 255}
+	Black     = color.RGBA{0, 0, 0, 255}
+)
+
+// DieColor возвращает цвет для числа на кубике
+func DieColor(number int) color.RGBA {
+	switch number {
+	case 1:
+		return Red
+	case 2:
+		return Orange
+	case 3:
+		return Yellow
+	case 4:
+		return Green
+	case 5:
+		return Blue
+	case 6:
+		return Purple
+	default:
+		return Gray
+	}
+}
+
+// Fade возвращает цвет с заданной прозрачностью от 0 до 1
+func Fade(c color.RGBA, alpha float32) color.RGBA {
+	c.A = uint8(255 * alpha)
+	return c
+}
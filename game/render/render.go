@@ -0,0 +1,44 @@
+// Package render содержит раскладку экрана KubeGame, общую для всех фронтендов.
+// Сам вывод выполняет Renderer: raylib, терминал, экспорт в картинку и т.д.
+package render
+
+import (
+	"image/color"
+
+	"kubegame/engine"
+)
+
+// Panel текстовая область экрана
+type Panel int
+
+const (
+	PanelSettings Panel = iota // выбор размера и параметров уровня
+	PanelStatus                // состояние текущей игры
+	PanelMessage               // сообщение поверх поля (например, о победе)
+)
+
+// TextStyle оформление строки текста
+type TextStyle struct {
+	Size  int // размер шрифта в пикселях, текстовые бэкенды могут его игнорировать
+	Color color.RGBA
+}
+
+// Renderer бэкенд отрисовки
+// Координаты клеток заданы в клетках сетки уровня, перевод в пиксели
+// или символы терминала выполняет бэкенд
+type Renderer interface {
+	// DrawCell закрашивает клетку пола
+	DrawCell(x, y int, fill color.RGBA)
+	// DrawWall рисует стену в клетке
+	DrawWall(x, y int)
+	// DrawLabel пишет короткую подпись поверх клетки
+	DrawLabel(x, y int, text string, c color.RGBA)
+	// DrawArrow рисует стрелку направления в клетке
+	DrawArrow(x, y int, dir engine.Direction, c color.RGBA)
+	// DrawDie рисует кубик с видимыми боковыми гранями
+	DrawDie(x, y int, die engine.Die)
+	// DrawPanel рисует фон текстовой панели на заданное число строк
+	DrawPanel(panel Panel, lines int)
+	// DrawText пишет строку текста в панели
+	DrawText(panel Panel, line int, text string, style TextStyle)
+}
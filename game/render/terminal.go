@@ -0,0 +1,188 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+
+	"kubegame/engine"
+)
+
+// TerminalCellWidth ширина клетки поля в символах терминала
+const TerminalCellWidth = 3
+
+// Фон терминала, на который накладываются полупрозрачные цвета
+var terminalBackground = color.RGBA{245, 245, 245, 255}
+
+// terminalCell символ поля терминала с цветами
+type terminalCell struct {
+	ch     rune
+	fg, bg color.RGBA
+}
+
+// TerminalRenderer рисует раскладку render в терминал с цветами ANSI (24 бита)
+type TerminalRenderer struct {
+	width, height int
+	board         [][]terminalCell
+	panels        map[Panel][]string
+	die           *engine.Die
+}
+
+// NewTerminalRenderer создает рендерер для поля width x height клеток
+func NewTerminalRenderer(width, height int) *TerminalRenderer {
+	r := &TerminalRenderer{
+		width:  width,
+		height: height,
+		board:  make([][]terminalCell, height),
+		panels: make(map[Panel][]string),
+	}
+	for y := range r.board {
+		r.board[y] = make([]terminalCell, width*TerminalCellWidth)
+		for x := range r.board[y] {
+			r.board[y][x] = terminalCell{ch: ' ', fg: Black, bg: terminalBackground}
+		}
+	}
+	return r
+}
+
+// span возвращает символы клетки (x, y) или nil, если клетка вне поля
+func (r *TerminalRenderer) span(x, y int) []terminalCell {
+	if x < 0 || x >= r.width || y < 0 || y >= r.height {
+		return nil
+	}
+	return r.board[y][x*TerminalCellWidth : (x+1)*TerminalCellWidth]
+}
+
+// putText пишет текст по центру клетки; не поместившийся хвост обрезается
+func (r *TerminalRenderer) putText(x, y int, text string, c color.RGBA) {
+	cells := r.span(x, y)
+	if cells == nil {
+		return
+	}
+	runes := []rune(text)
+	if len(runes) > len(cells) {
+		runes = runes[:len(cells)]
+	}
+	start := (len(cells) - len(runes)) / 2
+	for i, ch := range runes {
+		cells[start+i].ch = ch
+		cells[start+i].fg = c
+	}
+}
+
+// DrawCell закрашивает клетку, смешивая полупрозрачный цвет с текущим фоном
+func (r *TerminalRenderer) DrawCell(x, y int, fill color.RGBA) {
+	cells := r.span(x, y)
+	for i := range cells {
+		cells[i].bg = blend(fill, cells[i].bg)
+		cells[i].ch = ' '
+	}
+}
+
+// DrawWall рисует стену символами, чтобы поле читалось и без цветов
+func (r *TerminalRenderer) DrawWall(x, y int) {
+	cells := r.span(x, y)
+	for i := range cells {
+		cells[i] = terminalCell{ch: '#', fg: Black, bg: DarkBrown}
+	}
+}
+
+// DrawLabel пишет подпись по центру клетки
+func (r *TerminalRenderer) DrawLabel(x, y int, text string, c color.RGBA) {
+	r.putText(x, y, text, c)
+}
+
+// DrawArrow рисует стрелку направления
+func (r *TerminalRenderer) DrawArrow(x, y int, dir engine.Direction, c color.RGBA) {
+	arrows := map[engine.Direction]string{
+		engine.Up:    "↑",
+		engine.Down:  "↓",
+		engine.Left:  "←",
+		engine.Right: "→",
+	}
+	r.putText(x, y, arrows[dir], c)
+}
+
+// DrawDie рисует кубик числом сверху на его цвете, боковые грани выводятся под полем
+func (r *TerminalRenderer) DrawDie(x, y int, die engine.Die) {
+	r.DrawCell(x, y, DieColor(die.CurrentTop))
+	r.putText(x, y, fmt.Sprintf("[%d]", die.CurrentTop), White)
+	r.die = &die
+}
+
+// DrawPanel в терминале ничего не делает: панели выводятся текстом
+func (r *TerminalRenderer) DrawPanel(panel Panel, lines int) {}
+
+// DrawText запоминает строку панели для вывода в Flush
+func (r *TerminalRenderer) DrawText(panel Panel, line int, text string, style TextStyle) {
+	lines := r.panels[panel]
+	for len(lines) <= line {
+		lines = append(lines, "")
+	}
+	lines[line] = foreground(style.Color) + text + ansiReset
+	r.panels[panel] = lines
+}
+
+// Flush выводит кадр: настройки, поле, грани кубика, состояние и сообщение
+func (r *TerminalRenderer) Flush(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString(ansiClear)
+
+	for _, line := range r.panels[PanelSettings] {
+		b.WriteString(line + "\r\n")
+	}
+	b.WriteString("\r\n")
+
+	for _, row := range r.board {
+		for _, cell := range row {
+			b.WriteString(background(cell.bg) + foreground(cell.fg) + string(cell.ch))
+		}
+		b.WriteString(ansiReset + "\r\n")
+	}
+	b.WriteString("\r\n")
+
+	// Боковые грани кубика, как полоски вокруг кубика в окне raylib
+	if r.die != nil {
+		face := func(number int) string {
+			return background(DieColor(number)) + foreground(White) + fmt.Sprintf(" %d ", number) + ansiReset
+		}
+		b.WriteString("         " + face(r.die.Back) + "\r\n")
+		b.WriteString("Die:  " + face(r.die.Left) + face(r.die.CurrentTop) + face(r.die.Right) + "\r\n")
+		b.WriteString("         " + face(r.die.Front) + "\r\n\r\n")
+	}
+
+	for _, line := range r.panels[PanelStatus] {
+		b.WriteString(line + "\r\n")
+	}
+	for _, line := range r.panels[PanelMessage] {
+		b.WriteString("\r\n" + background(Green) + " " + line + " " + ansiReset + "\r\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Управляющие последовательности ANSI
+const (
+	ansiReset = "\x1b[0m"
+	ansiClear = "\x1b[H\x1b[2J"
+)
+
+// foreground возвращает последовательность цвета текста
+func foreground(c color.RGBA) string {
+	return fmt.Sprintf("\x1b[38;2;%d;%d;%dm", c.R, c.G, c.B)
+}
+
+// background возвращает последовательность цвета фона
+func background(c color.RGBA) string {
+	return fmt.Sprintf("\x1b[48;2;%d;%d;%dm", c.R, c.G, c.B)
+}
+
+// blend накладывает цвет c с его прозрачностью на непрозрачный фон
+func blend(c, bg color.RGBA) color.RGBA {
+	mix := func(a, b uint8) uint8 {
+		return uint8((int(a)*int(c.A) + int(b)*(255-int(c.A))) / 255)
+	}
+	return color.RGBA{mix(c.R, bg.R), mix(c.G, bg.G), mix(c.B, bg.B), 255}
+}
@@ -0,0 +1,87 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"kubegame/engine"
+)
+
+func TestTerminalRendererFlush(t *testing.T) {
+	r := NewTerminalRenderer(2, 1)
+	r.DrawText(PanelSettings, 1, "R: New level", TextStyle{14, DarkGray})
+	r.DrawWall(1, 0)
+	r.DrawDie(0, 0, engine.NewDie())
+	r.DrawText(PanelStatus, 0, "Target: 6", TextStyle{24, Black})
+	r.DrawText(PanelMessage, 0, "YOU WIN!", TextStyle{30, White})
+
+	var out strings.Builder
+	if err := r.Flush(&out); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	// Цвета: 80;80;80 DarkGray, 0;0;0 Black, 76;63;47 DarkBrown, 230;41;55 Red (1),
+	// 255;161;0 Orange (2), 253;249;0 Yellow (3), 0;228;48 Green (4), 0;121;241 Blue (5)
+	want := "\x1b[H\x1b[2J" +
+		// Панель настроек: пропущенная строка остается пустой
+		"\r\n" +
+		"\x1b[38;2;80;80;80mR: New level\x1b[0m\r\n" +
+		"\r\n" +
+		// Поле: кубик с единицей сверху и стена
+		"\x1b[48;2;230;41;55m\x1b[38;2;255;255;255m[" +
+		"\x1b[48;2;230;41;55m\x1b[38;2;255;255;255m1" +
+		"\x1b[48;2;230;41;55m\x1b[38;2;255;255;255m]" +
+		"\x1b[48;2;76;63;47m\x1b[38;2;0;0;0m#" +
+		"\x1b[48;2;76;63;47m\x1b[38;2;0;0;0m#" +
+		"\x1b[48;2;76;63;47m\x1b[38;2;0;0;0m#" +
+		"\x1b[0m\r\n" +
+		"\r\n" +
+		// Грани кубика: сзади 5, слева 3, сверху 1, справа 4, спереди 2
+		"         \x1b[48;2;0;121;241m\x1b[38;2;255;255;255m 5 \x1b[0m\r\n" +
+		"Die:  \x1b[48;2;253;249;0m\x1b[38;2;255;255;255m 3 \x1b[0m" +
+		"\x1b[48;2;230;41;55m\x1b[38;2;255;255;255m 1 \x1b[0m" +
+		"\x1b[48;2;0;228;48m\x1b[38;2;255;255;255m 4 \x1b[0m\r\n" +
+		"         \x1b[48;2;255;161;0m\x1b[38;2;255;255;255m 2 \x1b[0m\r\n\r\n" +
+		// Состояние и сообщение поверх поля
+		"\x1b[38;2;0;0;0mTarget: 6\x1b[0m\r\n" +
+		"\r\n\x1b[48;2;0;228;48m \x1b[38;2;255;255;255mYOU WIN!\x1b[0m \x1b[0m\r\n"
+	if got := out.String(); got != want {
+		t.Errorf("Flush() wrote\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestTerminalRendererBlend(t *testing.T) {
+	r := NewTerminalRenderer(1, 1)
+	r.DrawCell(0, 0, Fade(Black, 0.5))
+	r.DrawArrow(0, 0, engine.Left, White)
+	r.DrawLabel(5, 5, "x", White) // вне поля ничего не рисуется
+
+	var out strings.Builder
+	if err := r.Flush(&out); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	// Половина черного поверх фона 245;245;245 дает 122;122;122
+	cell := "\x1b[48;2;122;122;122m\x1b[38;2;0;0;0m \x1b[48;2;122;122;122m\x1b[38;2;255;255;255m←\x1b[48;2;122;122;122m\x1b[38;2;0;0;0m \x1b[0m\r\n"
+	if !strings.Contains(out.String(), cell) {
+		t.Errorf("Flush() wrote %q, want the board row %q", out.String(), cell)
+	}
+}
+
+func TestTerminalRendererLabel(t *testing.T) {
+	r := NewTerminalRenderer(2, 1)
+	r.DrawLabel(0, 0, "!A", Black)
+	r.DrawLabel(1, 0, "!123", Black) // не поместившийся хвост обрезается
+
+	var out strings.Builder
+	if err := r.Flush(&out); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	var row strings.Builder
+	for _, ch := range "!A !12" {
+		row.WriteString("\x1b[48;2;245;245;245m\x1b[38;2;0;0;0m" + string(ch))
+	}
+	row.WriteString("\x1b[0m\r\n")
+	if !strings.Contains(out.String(), row.String()) {
+		t.Errorf("Flush() wrote %q, want the board row %q", out.String(), row.String())
+	}
+}